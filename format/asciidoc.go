@@ -0,0 +1,196 @@
+package format
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// asciidocDialect AsciiDoc（Asciidoctor / Antora）
+type asciidocDialect struct{}
+
+func (asciidocDialect) ext() string {
+	return "adoc"
+}
+
+func (d asciidocDialect) document(article parse.Article, body string) string {
+	title, _ := article.Title.Val.(string)
+	head := "= " + d.escape(title) + "\n"
+	md := article.Metadata
+	if author := firstNonEmpty(md.Author, md.AccountName); author != "" {
+		head += author + "\n"
+	}
+	if !md.PublishTime.IsZero() {
+		head += ":revdate: " + md.PublishTime.Format("2006-01-02") + "\n"
+	}
+	if tags := splitTags(article.Tags); len(tags) > 0 {
+		head += ":keywords: " + strings.Join(tags, ", ") + "\n"
+	}
+	if hasMath(article.Content) {
+		head += ":stem: latexmath\n"
+	}
+	return head + "\n" + body + "\n"
+}
+
+// header 一级的 = 留给文档标题，正文从 == 开始
+func (asciidocDialect) header(text string, level int) string {
+	return strings.Repeat("=", level+1) + " " + text
+}
+
+// inline 都用不受前后字符限制的双写形式，中文旁边也能生效
+func (asciidocDialect) inline(text string, marks map[string]bool) string {
+	if marks[parse.MARK_CODE] {
+		text = "``" + text + "``"
+	}
+	if marks[parse.MARK_SUP] {
+		text = "^" + text + "^"
+	}
+	if marks[parse.MARK_SUB] {
+		text = "~" + text + "~"
+	}
+	if marks[parse.MARK_HIGHLIGHT] && !marks[parse.MARK_UNDERLINE] && !marks[parse.MARK_STRIKE] {
+		// 不带角色的 ## 即为高亮，和带角色的下划线、删除线无法嵌套
+		text = "##" + text + "##"
+	}
+	if marks[parse.MARK_UNDERLINE] {
+		text = "[.underline]##" + text + "##"
+	}
+	if marks[parse.MARK_STRIKE] {
+		text = "[.line-through]##" + text + "##"
+	}
+	if marks[parse.MARK_ITALIC] {
+		text = "__" + text + "__"
+	}
+	if marks[parse.MARK_BOLD] {
+		text = "**" + text + "**"
+	}
+	return text
+}
+
+func (asciidocDialect) separator(r rune, link bool) string {
+	return ""
+}
+
+// asciidocLineStart 行首的这些内容会被当成章节标题、列表、块标题、分隔线、表格、注释、属性或提示块
+var asciidocLineStart = regexp.MustCompile(`^\s*(?:=+\s|[*.-]+\s|\.\S|\|===|-{4,}|_{4,}|\*{4,}|={4,}|\+{4,}|//|'{3}|:[^:\s]*:|\[|(?:NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s|\d+\.\s)`)
+
+// escape 可能被当成格式、属性引用或宏的文字放进 pass:c[] 里原样输出（只转义 HTML 特殊字符），
+// 行首的块标记也因此不再位于行首；结尾的反斜杠会转义掉 ]，改用 {backslash} 写在外面
+func (asciidocDialect) escape(text string) string {
+	if !asciidocLineStart.MatchString(text) && !strings.ContainsAny(text, "*_`#^~+{[\\") && !strings.Contains(text, "<<") {
+		return text
+	}
+	core := strings.TrimRight(text, "\\")
+	trail := strings.Repeat("{backslash}", len(text)-len(core))
+	if core == "" {
+		return trail
+	}
+	return "pass:c[" + strings.ReplaceAll(core, "]", "\\]") + "]" + trail
+}
+
+func (asciidocDialect) link(text string, href string) string {
+	return "link:++" + href + "++[" + strings.ReplaceAll(text, "]", "\\]") + "]"
+}
+
+func (asciidocDialect) image(alt string, src string) string {
+	return "image::" + src + "[" + strings.ReplaceAll(alt, "]", "\\]") + "]"
+}
+
+func (asciidocDialect) inlineImage(alt string, src string) string {
+	return "image:" + src + "[" + strings.ReplaceAll(alt, "]", "\\]") + "]"
+}
+
+// codeBlock 代码里有和分隔线一样的行时加长分隔线
+func (asciidocDialect) codeBlock(rows []string, lang string) string {
+	delimiter := "----"
+	for _, row := range rows {
+		if strings.TrimRight(row, " \t") == delimiter {
+			delimiter += "-"
+		}
+	}
+	head := "[source]"
+	if lang != "" {
+		head = "[source," + lang + "]"
+	}
+	return head + "\n" + delimiter + "\n" + strings.Join(rows, "\n") + "\n" + delimiter
+}
+
+// math 公式用 latexmath，文档头中要有 :stem: 才会渲染
+func (asciidocDialect) math(tex string, display bool) string {
+	if display {
+		return "[latexmath]\n++++\n" + tex + "\n++++"
+	}
+	return "latexmath:[" + strings.ReplaceAll(tex, "]", "\\]") + "]"
+}
+
+// quote 嵌套的引用用更长的分隔线区分
+func (asciidocDialect) quote(body string, depth int) string {
+	delimiter := "____" + strings.Repeat("_", depth)
+	return delimiter + "\n" + body + "\n" + delimiter
+}
+
+// list 嵌套层级用重复的标记表示，列表项里的其他块用 + 接续
+func (asciidocDialect) list(items []markupItem, ordered bool, depth int) string {
+	marker := strings.Repeat("*", depth) + " "
+	if ordered {
+		marker = strings.Repeat(".", depth) + " "
+	}
+	var texts []string
+	if len(items) > 0 && ordered {
+		if start := firstNonEmpty(items[0].number, "1"); start != "1" {
+			texts = append(texts, "[start="+start+"]")
+		}
+	}
+	for _, item := range items {
+		text := marker
+		for i, block := range item.blocks {
+			switch {
+			case i == 0:
+				text += block.text
+			case block.list:
+				text += "\n" + block.text
+			default:
+				text += "\n+\n" + block.text
+			}
+		}
+		texts = append(texts, strings.TrimRight(text, " "))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// table 合并单元格写成 2.3+| 这样的跨列/跨行前缀
+func (asciidocDialect) table(rows [][]markupCell, hasHeader bool, cols int) string {
+	head := "[cols=\"" + strings.TrimSuffix(strings.Repeat("1,", cols), ",") + "\""
+	if hasHeader {
+		head += ",options=\"header\""
+	}
+	lines := []string{head + "]", "|==="}
+	for _, row := range rows {
+		var line string
+		for _, cell := range row {
+			if cell.covered {
+				continue
+			}
+			var span string
+			if cell.colSpan > 1 {
+				span = strconv.Itoa(cell.colSpan)
+			}
+			if cell.rowSpan > 1 {
+				span += "." + strconv.Itoa(cell.rowSpan)
+			}
+			if span != "" {
+				span += "+"
+			}
+			line += span + "| " + strings.ReplaceAll(cell.text, "|", "\\|") + " "
+		}
+		lines = append(lines, strings.TrimRight(line, " "), "")
+	}
+	lines[len(lines)-1] = "|==="
+	return strings.Join(lines, "\n")
+}
+
+func (asciidocDialect) hr() string {
+	return "'''"
+}
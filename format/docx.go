@@ -0,0 +1,612 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// docxRenderer 把文章输出成 Word 文档（OOXML），图片嵌在文档内
+type docxRenderer struct{}
+
+func (docxRenderer) Render(article parse.Article, opts Options) ([]byte, map[string][]byte, error) {
+	doc, err := RenderDocx(article)
+	return doc, nil, err
+}
+
+func (docxRenderer) Ext() string {
+	return "docx"
+}
+
+const (
+	// A4 纸张、上下左右各 1 英寸页边距时的正文宽度（twip）
+	docxTextWidth = 11906 - 1440*2
+	// 1 twip = 635 EMU；图片最宽不超过正文宽度
+	docxMaxImageWidth = docxTextWidth * 635
+	// 96 DPI 下 1 像素 = 9525 EMU
+	docxEMUPerPixel = 9525
+	// 列表每一级的缩进（twip）
+	docxListIndent = 720
+)
+
+// docxWriter 输出 document.xml 时收集图片、超链接关系和列表编号
+type docxWriter struct {
+	rels      []string
+	media     map[string][]byte
+	mediaRels map[string]string
+	nums      []string
+	numCount  int
+	relCount  int
+	picCount  int
+}
+
+// docxBlockCtx 段落所处的环境
+type docxBlockCtx struct {
+	// style 段落样式，如引用块内为 Quote
+	style string
+	// listLevel 列表嵌套层级，不在列表中时为 -1
+	listLevel int
+	// numPr 列表项的第一个段落带上编号，用过后清空
+	numPr string
+	// align 表格单元格的对齐方式
+	align string
+}
+
+// RenderDocx 把文章输出成 docx 文件
+func RenderDocx(article parse.Article) ([]byte, error) {
+	w := &docxWriter{media: make(map[string][]byte), mediaRels: make(map[string]string), relCount: 2}
+	md := article.Metadata
+	title, _ := article.Title.Val.(string)
+	title = strings.TrimSpace(title)
+
+	var body string = docxParagraph("Title", "", docxTextRun(title, ""))
+	var meta []string
+	for _, val := range []string{md.Author, md.AccountName} {
+		if val != "" && !containsString(meta, val) {
+			meta = append(meta, val)
+		}
+	}
+	if !md.PublishTime.IsZero() {
+		meta = append(meta, md.PublishTime.Format("2006-01-02 15:04"))
+	}
+	if len(meta) > 0 {
+		body += docxParagraph("Subtitle", "", docxTextRun(strings.Join(meta, " · "), ""))
+	}
+	body += w.blocks(article.Content, &docxBlockCtx{listLevel: -1})
+	if md.URL != "" {
+		body += docxParagraph("Subtitle", "", docxTextRun("原文链接：", "")+w.hyperlink(md.URL, md.URL))
+	}
+	if md.ShareURL != "" || md.ShareAccount != "" {
+		text := firstNonEmpty(md.ShareAccount, md.ShareURL)
+		link := docxTextRun(text, "")
+		if md.ShareURL != "" {
+			link = w.hyperlink(text, md.ShareURL)
+		}
+		body += docxParagraph("Subtitle", "", docxTextRun("转载自：", "")+link)
+	}
+	if md.SourceURL != "" {
+		body += docxParagraph("Subtitle", "", w.hyperlink("阅读原文", md.SourceURL))
+	}
+
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">
+<w:body>
+` + body + `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="851" w:footer="992" w:gutter="0"/></w:sectPr>
+</w:body>
+</w:document>
+`
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+` + strings.Join(w.rels, "") + `</Relationships>
+`
+
+	contentTypes := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+`
+	exts := make(map[string]bool)
+	for _, name := range sortedKeys(w.media) {
+		ext := name[strings.LastIndex(name, ".")+1:]
+		if !exts[ext] {
+			exts[ext] = true
+			contentTypes += "<Default Extension=\"" + ext + "\" ContentType=\"" + imageMediaType(name, w.media[name]) + "\"/>\n"
+		}
+	}
+	contentTypes += `<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>
+`
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", []byte(contentTypes)},
+		{"_rels/.rels", []byte(docxPackageRels)},
+		{"docProps/core.xml", []byte(docxCoreProps(title, firstNonEmpty(md.Author, md.AccountName), md.PublishTime))},
+		{"word/document.xml", []byte(xmlCharsOnly(document))},
+		{"word/styles.xml", []byte(docxStyles)},
+		{"word/numbering.xml", []byte(docxNumbering(w.nums))},
+		{"word/_rels/document.xml.rels", []byte(rels)},
+	}
+	for _, name := range sortedKeys(w.media) {
+		files = append(files, struct {
+			name    string
+			content []byte
+		}{"word/media/" + name, w.media[name]})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// blocks 行内内容以换行为界分成段落，其余的按块输出
+func (w *docxWriter) blocks(pieces []parse.Piece, ctx *docxBlockCtx) string {
+	var xmlStr string
+	var inline []parse.Piece
+	flush := func() {
+		var runs string
+		blank := true
+		for _, piece := range inline {
+			if piece.Type == parse.IMAGE || piece.Type == parse.IMAGE_BASE64 {
+				blank = false
+			} else if text, _ := piece.Val.(string); !isBlankText(text) {
+				blank = false
+			}
+			runs += w.inline(piece)
+		}
+		inline = nil
+		if !blank {
+			xmlStr += w.paragraph(ctx, "", runs)
+		}
+	}
+	var numID int
+	for i, piece := range pieces {
+		if isHTMLInline(piece) {
+			inline = append(inline, piece)
+			continue
+		}
+		flush()
+		switch piece.Type {
+		case parse.HEADER, parse.O_LIST, parse.U_LIST, parse.TABLE, parse.HR:
+			if ctx.numPr != "" {
+				// 列表项以标题、嵌套列表、表格等开头时，先输出一个带编号的空段落，编号才会在内容前面
+				xmlStr += w.paragraph(ctx, "", "")
+			}
+		}
+		switch piece.Type {
+		case parse.HEADER:
+			level, _ := strconv.Atoi(piece.Attrs["level"])
+			if level < 1 || level > 6 {
+				level = 2
+			}
+			xmlStr += docxParagraph("Heading"+strconv.Itoa(level), "", docxTextRun(strings.TrimSpace(piece.Val.(string)), ""))
+		case parse.CODE_BLOCK:
+			for _, row := range piece.Val.([]string) {
+				xmlStr += w.paragraph(ctx, "Code", docxTextRun(row, ""))
+			}
+		case parse.MATH:
+			// 独占一行的公式按代码块的样式输出 TeX 源码
+			for _, row := range strings.Split(mathTeX(piece), "\n") {
+				xmlStr += w.paragraph(ctx, "Code", docxTextRun(row, ""))
+			}
+		case parse.BLOCK_QUOTES:
+			quoteCtx := *ctx
+			quoteCtx.style = "Quote"
+			xmlStr += w.blocks(piece.Val.([]parse.Piece), &quoteCtx)
+			ctx.numPr = quoteCtx.numPr
+		case parse.O_LIST, parse.U_LIST:
+			// 相邻的同类列表项是同一个列表，有序列表每个列表单独编号
+			if i == 0 || pieces[i-1].Type != piece.Type {
+				numID = 1
+				if piece.Type == parse.O_LIST {
+					numID = w.orderedNum(ctx.listLevel+1, piece.Attrs["number"])
+				}
+			}
+			itemCtx := docxBlockCtx{style: ctx.style, listLevel: ctx.listLevel + 1}
+			ilvl := itemCtx.listLevel
+			if ilvl > 8 {
+				// Word 最多支持 9 级列表
+				ilvl = 8
+			}
+			itemCtx.numPr = "<w:numPr><w:ilvl w:val=\"" + strconv.Itoa(ilvl) + "\"/><w:numId w:val=\"" + strconv.Itoa(numID) + "\"/></w:numPr>"
+			xmlStr += w.blocks(piece.Val.([]parse.Piece), &itemCtx)
+			if itemCtx.numPr != "" {
+				// 空的列表项也要占一个编号
+				xmlStr += w.paragraph(&itemCtx, "", "")
+			}
+		case parse.TABLE:
+			if table, ok := piece.Val.(parse.Table); ok {
+				xmlStr += w.table(table)
+			}
+		case parse.VIDEO:
+			link := firstNonEmpty(piece.Attrs["src"], piece.Attrs["embed"], piece.Attrs["file"])
+			xmlStr += w.paragraph(ctx, "", w.linkOrText("▶ "+mediaTitle(piece, "视频"), link))
+		case parse.AUDIO:
+			xmlStr += w.paragraph(ctx, "", w.linkOrText("♪ "+mediaTitle(piece, "音频"), piece.Attrs["src"]))
+		case parse.EMBED_CARD:
+			runs := w.linkOrText(firstNonEmpty(piece.Attrs["title"], piece.Attrs["url"]), piece.Attrs["url"])
+			if desc := piece.Attrs["desc"]; desc != "" {
+				runs += "<w:r><w:br/></w:r>" + docxTextRun(desc, "")
+			}
+			xmlStr += w.paragraph(ctx, "Quote", runs)
+		case parse.HR:
+			xmlStr += "<w:p><w:pPr><w:pBdr><w:bottom w:val=\"single\" w:sz=\"6\" w:space=\"1\" w:color=\"auto\"/></w:pBdr></w:pPr></w:p>\n"
+		}
+	}
+	flush()
+	return xmlStr
+}
+
+// paragraph 列表项的第一个段落带编号，后面的段落与之对齐；style 为空时使用环境的段落样式
+func (w *docxWriter) paragraph(ctx *docxBlockCtx, style string, runs string) string {
+	var pPr string
+	if ctx.numPr != "" {
+		pPr = ctx.numPr
+		ctx.numPr = ""
+	} else {
+		pPr = docxIndent(ctx.listLevel)
+	}
+	if ctx.align != "" {
+		pPr += "<w:jc w:val=\"" + ctx.align + "\"/>"
+	}
+	if style == "" {
+		style = ctx.style
+	}
+	if style == "" && ctx.listLevel >= 0 {
+		style = "ListParagraph"
+	}
+	return docxParagraph(style, pPr, runs)
+}
+
+func docxParagraph(style string, pPr string, runs string) string {
+	if style != "" {
+		pPr = "<w:pStyle w:val=\"" + style + "\"/>" + pPr
+	}
+	if pPr != "" {
+		pPr = "<w:pPr>" + pPr + "</w:pPr>"
+	}
+	return "<w:p>" + pPr + runs + "</w:p>\n"
+}
+
+func docxIndent(listLevel int) string {
+	if listLevel < 0 {
+		return ""
+	}
+	return "<w:ind w:left=\"" + strconv.Itoa(docxListIndent*(listLevel+1)) + "\"/>"
+}
+
+// orderedNum 新建一个有序列表的编号实例，从 start 开始编号
+func (w *docxWriter) orderedNum(level int, start string) int {
+	if level > 8 {
+		level = 8
+	}
+	n, err := strconv.Atoi(start)
+	if err != nil || n < 0 {
+		n = 1
+	}
+	// numId 1 留给无序列表
+	w.numCount++
+	numID := w.numCount + 1
+	w.nums = append(w.nums, "<w:num w:numId=\""+strconv.Itoa(numID)+"\"><w:abstractNumId w:val=\"1\"/>"+
+		"<w:lvlOverride w:ilvl=\""+strconv.Itoa(level)+"\"><w:startOverride w:val=\""+strconv.Itoa(n)+"\"/></w:lvlOverride></w:num>\n")
+	return numID
+}
+
+// inline 行内内容输出成 run
+func (w *docxWriter) inline(piece parse.Piece) string {
+	switch piece.Type {
+	case parse.NORMAL_TEXT:
+		return docxTextRun(blankReg.ReplaceAllString(piece.Val.(string), " "), "")
+	case parse.BOLD_TEXT, parse.ITALIC_TEXT, parse.BOLD_ITALIC_TEXT,
+		parse.STRIKETHROUGH_TEXT, parse.UNDERLINE_TEXT, parse.HIGHLIGHT_TEXT, parse.SUP_TEXT, parse.SUB_TEXT,
+		parse.CODE_INLINE:
+		return docxTextRun(blankReg.ReplaceAllString(piece.Val.(string), " "), docxMarkProps(piece))
+	case parse.LINK:
+		return w.hyperlink(piece.Val.(string), piece.Attrs["href"])
+	case parse.MATH:
+		return docxTextRun("$"+mathTeX(piece)+"$", "<w:rStyle w:val=\"CodeChar\"/>")
+	case parse.IMAGE:
+		if piece.Val == nil {
+			// 只有链接的图片不嵌入文档
+			return w.hyperlink(firstNonEmpty(piece.Attrs["alt"], "图片"), piece.Attrs["src"])
+		}
+		return w.embedImage(piece)
+	case parse.IMAGE_BASE64:
+		data, err := base64.StdEncoding.DecodeString(piece.Val.(string))
+		if err != nil {
+			return ""
+		}
+		return w.embedImage(parse.Piece{Type: parse.IMAGE, Val: data, Attrs: piece.Attrs})
+	}
+	return ""
+}
+
+// docxMarkProps 修饰标记对应的 run 属性，顺序需符合 OOXML 的要求
+func docxMarkProps(piece parse.Piece) string {
+	marks := defaultMarks[piece.Type]
+	if piece.Attrs["marks"] != "" {
+		marks = strings.Split(piece.Attrs["marks"], ",")
+	}
+	has := make(map[string]bool)
+	for _, m := range marks {
+		has[m] = true
+	}
+	var rPr string
+	if has[parse.MARK_CODE] {
+		rPr += "<w:rStyle w:val=\"CodeChar\"/>"
+	}
+	if has[parse.MARK_BOLD] {
+		rPr += "<w:b/>"
+	}
+	if has[parse.MARK_ITALIC] {
+		rPr += "<w:i/>"
+	}
+	if has[parse.MARK_STRIKE] {
+		rPr += "<w:strike/>"
+	}
+	if has[parse.MARK_HIGHLIGHT] {
+		rPr += "<w:highlight w:val=\"yellow\"/>"
+	}
+	if has[parse.MARK_UNDERLINE] {
+		rPr += "<w:u w:val=\"single\"/>"
+	}
+	if has[parse.MARK_SUP] {
+		rPr += "<w:vertAlign w:val=\"superscript\"/>"
+	} else if has[parse.MARK_SUB] {
+		rPr += "<w:vertAlign w:val=\"subscript\"/>"
+	}
+	return rPr
+}
+
+func docxTextRun(text string, rPr string) string {
+	if rPr != "" {
+		rPr = "<w:rPr>" + rPr + "</w:rPr>"
+	}
+	return "<w:r>" + rPr + "<w:t xml:space=\"preserve\">" + html.EscapeString(text) + "</w:t></w:r>"
+}
+
+func (w *docxWriter) linkOrText(text string, url string) string {
+	if url == "" {
+		return docxTextRun(text, "")
+	}
+	return w.hyperlink(text, url)
+}
+
+func (w *docxWriter) hyperlink(text string, url string) string {
+	if url == "" {
+		return docxTextRun(text, "")
+	}
+	rID := w.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", url, true)
+	return "<w:hyperlink r:id=\"" + rID + "\">" + docxTextRun(text, "<w:rStyle w:val=\"Hyperlink\"/>") + "</w:hyperlink>"
+}
+
+func (w *docxWriter) addRel(relType string, target string, external bool) string {
+	w.relCount++
+	rID := "rId" + strconv.Itoa(w.relCount)
+	rel := "<Relationship Id=\"" + rID + "\" Type=\"" + relType + "\" Target=\"" + html.EscapeString(target) + "\""
+	if external {
+		rel += " TargetMode=\"External\""
+	}
+	w.rels = append(w.rels, rel+"/>\n")
+	return rID
+}
+
+// docxImageTypes Word 能显示的图片类型
+var docxImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/bmp":  true,
+	"image/tiff": true,
+}
+
+// embedImage 嵌入带内容的图片。svg、webp 等 Word 显示不了的图片嵌进去只是一个破损的图片框，
+// 有些版本还会提示修复文档，和没有内容的图片一样改为链接到原图，没有原图地址（如内嵌的 svg）时只输出文字
+func (w *docxWriter) embedImage(piece parse.Piece) string {
+	if !docxImageTypes[imageType(piece)] {
+		return w.linkOrText(firstNonEmpty(piece.Attrs["alt"], "图片"), piece.Attrs["src"])
+	}
+	return w.image(piece.Val.([]byte), imageFileName(piece), piece.Attrs["alt"])
+}
+
+// image 图片嵌入文档，按像素大小显示，超过正文宽度时等比缩小
+func (w *docxWriter) image(data []byte, name string, alt string) string {
+	rID, ok := w.mediaRels[name]
+	if !ok {
+		rID = w.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "media/"+name, false)
+		w.mediaRels[name] = rID
+		w.media[name] = data
+	}
+	cx, cy := 480*docxEMUPerPixel, 360*docxEMUPerPixel
+	if width, height := parse.ImageSize(data); width > 0 && height > 0 {
+		cx, cy = width*docxEMUPerPixel, height*docxEMUPerPixel
+	}
+	if cx > docxMaxImageWidth {
+		cy = cy * docxMaxImageWidth / cx
+		cx = docxMaxImageWidth
+	}
+	w.picCount++
+	id := strconv.Itoa(w.picCount)
+	extent := "cx=\"" + strconv.Itoa(cx) + "\" cy=\"" + strconv.Itoa(cy) + "\""
+	return "<w:r><w:drawing><wp:inline distT=\"0\" distB=\"0\" distL=\"0\" distR=\"0\">" +
+		"<wp:extent " + extent + "/><wp:docPr id=\"" + id + "\" name=\"Picture " + id + "\" descr=\"" + html.EscapeString(alt) + "\"/>" +
+		"<a:graphic><a:graphicData uri=\"http://schemas.openxmlformats.org/drawingml/2006/picture\"><pic:pic>" +
+		"<pic:nvPicPr><pic:cNvPr id=\"" + id + "\" name=\"" + name + "\"/><pic:cNvPicPr/></pic:nvPicPr>" +
+		"<pic:blipFill><a:blip r:embed=\"" + rID + "\"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>" +
+		"<pic:spPr><a:xfrm><a:off x=\"0\" y=\"0\"/><a:ext " + extent + "/></a:xfrm><a:prstGeom prst=\"rect\"><a:avLst/></a:prstGeom></pic:spPr>" +
+		"</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>"
+}
+
+// table 输出原生表格，跨列用 gridSpan，跨行用 vMerge
+func (w *docxWriter) table(table parse.Table) string {
+	cols := table.ColCount()
+	if cols == 0 {
+		return ""
+	}
+	// 被上面的单元格跨行占住的位置 => 该单元格跨的列数
+	merged := make(map[[2]int]int)
+	colWidth := docxTextWidth / cols
+
+	xmlStr := "<w:tbl><w:tblPr><w:tblStyle w:val=\"TableGrid\"/><w:tblW w:w=\"5000\" w:type=\"pct\"/></w:tblPr><w:tblGrid>"
+	for i := 0; i < cols; i++ {
+		xmlStr += "<w:gridCol w:w=\"" + strconv.Itoa(colWidth) + "\"/>"
+	}
+	xmlStr += "</w:tblGrid>\n"
+	for r, row := range table.Rows {
+		xmlStr += "<w:tr>"
+		if r == 0 && table.HasHeader {
+			xmlStr += "<w:trPr><w:tblHeader/></w:trPr>"
+		}
+		c := 0
+		next := 0
+		for c < cols {
+			if span, ok := merged[[2]int{r, c}]; ok {
+				xmlStr += "<w:tc><w:tcPr>" + docxCellWidth(colWidth, span) + docxGridSpan(span) + "<w:vMerge/></w:tcPr><w:p/></w:tc>"
+				c += span
+				continue
+			}
+			if next >= len(row) {
+				// 不够一行的补上空单元格
+				xmlStr += "<w:tc><w:tcPr>" + docxCellWidth(colWidth, 1) + "</w:tcPr><w:p/></w:tc>"
+				c++
+				continue
+			}
+			cell := row[next]
+			next++
+			span := cell.ColSpan
+			if span < 1 {
+				span = 1
+			}
+			if c+span > cols {
+				span = cols - c
+			}
+			tcPr := docxCellWidth(colWidth, span) + docxGridSpan(span)
+			if cell.RowSpan > 1 {
+				tcPr += "<w:vMerge w:val=\"restart\"/>"
+				for k := 1; k < cell.RowSpan && r+k < len(table.Rows); k++ {
+					merged[[2]int{r + k, c}] = span
+				}
+			}
+			if cell.IsHeader {
+				tcPr += "<w:shd w:val=\"clear\" w:color=\"auto\" w:fill=\"F2F2F2\"/>"
+			}
+			var align string
+			switch cell.Align {
+			case "center":
+				align = "center"
+			case "right":
+				align = "right"
+			}
+			cellXML := w.blocks(cell.Content, &docxBlockCtx{listLevel: -1, align: align})
+			if cellXML == "" {
+				cellXML = "<w:p/>"
+			}
+			xmlStr += "<w:tc><w:tcPr>" + tcPr + "</w:tcPr>" + cellXML + "</w:tc>"
+			c += span
+		}
+		xmlStr += "</w:tr>\n"
+	}
+	// 表格后面紧跟表格时 Word 会把它们合并，用空段落隔开
+	return xmlStr + "</w:tbl>\n<w:p/>\n"
+}
+
+func docxCellWidth(colWidth int, span int) string {
+	return "<w:tcW w:w=\"" + strconv.Itoa(colWidth*span) + "\" w:type=\"dxa\"/>"
+}
+
+func docxGridSpan(span int) string {
+	if span <= 1 {
+		return ""
+	}
+	return "<w:gridSpan w:val=\"" + strconv.Itoa(span) + "\"/>"
+}
+
+func docxCoreProps(title string, creator string, created time.Time) string {
+	props := "<dc:title>" + html.EscapeString(title) + "</dc:title>"
+	if creator != "" {
+		props += "<dc:creator>" + html.EscapeString(creator) + "</dc:creator>"
+	}
+	if !created.IsZero() {
+		props += "<dcterms:created xsi:type=\"dcterms:W3CDTF\">" + created.UTC().Format("2006-01-02T15:04:05Z") + "</dcterms:created>"
+	}
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+` + props + `
+</cp:coreProperties>
+`
+}
+
+// docxNumbering abstractNum 0 为无序列表，1 为有序列表；每个有序列表用一个单独的 num 以便重新编号
+func docxNumbering(nums []string) string {
+	bullets := []string{"•", "◦", "▪"}
+	var bulletLvls, decimalLvls string
+	for i := 0; i < 9; i++ {
+		ind := fmt.Sprintf("<w:pPr><w:ind w:left=\"%d\" w:hanging=\"360\"/></w:pPr>", docxListIndent*(i+1))
+		bulletLvls += fmt.Sprintf("<w:lvl w:ilvl=\"%d\"><w:start w:val=\"1\"/><w:numFmt w:val=\"bullet\"/><w:lvlText w:val=\"%s\"/><w:lvlJc w:val=\"left\"/>%s</w:lvl>", i, bullets[i%len(bullets)], ind)
+		decimalLvls += fmt.Sprintf("<w:lvl w:ilvl=\"%d\"><w:start w:val=\"1\"/><w:numFmt w:val=\"decimal\"/><w:lvlText w:val=\"%%%d.\"/><w:lvlJc w:val=\"left\"/>%s</w:lvl>", i, i+1, ind)
+	}
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:abstractNum w:abstractNumId="0"><w:multiLevelType w:val="hybridMultilevel"/>` + bulletLvls + `</w:abstractNum>
+<w:abstractNum w:abstractNumId="1"><w:multiLevelType w:val="hybridMultilevel"/>` + decimalLvls + `</w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>
+` + strings.Join(nums, "") + `</w:numbering>
+`
+}
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>
+`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Microsoft YaHei" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US" w:eastAsia="zh-CN"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="320" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="40"/><w:szCs w:val="40"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Subtitle"><w:name w:val="Subtitle"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:rPr><w:color w:val="888888"/><w:sz w:val="20"/><w:szCs w:val="20"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="160"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/><w:szCs w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="320" w:after="140"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/><w:szCs w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="280" w:after="120"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/><w:szCs w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/><w:szCs w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="100"/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/><w:sz w:val="22"/><w:szCs w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="200" w:after="100"/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:b/><w:i/><w:sz w:val="22"/><w:szCs w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="DDDDDD"/></w:pBdr><w:ind w:left="360"/></w:pPr><w:rPr><w:color w:val="666666"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="60"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/><w:spacing w:after="0" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:sz w:val="18"/><w:szCs w:val="18"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="576B95"/><w:u w:val="single"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:left w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:right w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="BFBFBF"/></w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>
+`
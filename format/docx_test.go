@@ -0,0 +1,93 @@
+package format
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestDocxImageFallback(t *testing.T) {
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg"><rect width="1" height="1"/></svg>`)
+	webp := []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+	article := parse.Article{
+		Title: parse.Piece{Type: parse.HEADER, Val: "图片", Attrs: map[string]string{"level": "1"}},
+		Content: []parse.Piece{
+			{Type: parse.IMAGE, Val: pngData(t), Attrs: map[string]string{"src": "https://mmbiz.qpic.cn/a", "alt": "位图"}},
+			{Type: parse.BR},
+			{Type: parse.IMAGE_BASE64, Val: base64.StdEncoding.EncodeToString(svg), Attrs: map[string]string{"src": "", "alt": "矢量图"}},
+			{Type: parse.BR},
+			{Type: parse.IMAGE, Val: webp, Attrs: map[string]string{"src": "https://mmbiz.qpic.cn/b?wx_fmt=webp", "alt": ""}},
+		},
+	}
+	out, _, err := docxRenderer{}.Render(article, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := unzipFiles(t, out)
+	var media []string
+	for name := range files {
+		if strings.HasPrefix(name, "word/media/") {
+			media = append(media, name)
+		}
+	}
+	if len(media) != 1 || !strings.HasSuffix(media[0], ".png") {
+		t.Errorf("embedded media = %v, want only the png", media)
+	}
+	doc := files["word/document.xml"]
+	if !strings.Contains(doc, ">矢量图</w:t>") {
+		t.Error("svg without a source url should fall back to its alt text")
+	}
+	if !strings.Contains(files["word/_rels/document.xml.rels"], `Target="https://mmbiz.qpic.cn/b?wx_fmt=webp" TargetMode="External"`) {
+		t.Error("webp image should fall back to a hyperlink to its source")
+	}
+	if strings.Count(doc, "<w:drawing>") != 1 {
+		t.Errorf("document has %d drawings, want 1", strings.Count(doc, "<w:drawing>"))
+	}
+}
+
+func TestDocxListItemStartsWithBlock(t *testing.T) {
+	table := parse.Piece{Type: parse.TABLE, Val: parse.Table{Rows: []parse.TableRow{{cell("单元格")}}}}
+	tests := []struct {
+		name  string
+		item  parse.Piece
+		numPr string // 列表项编号所在段落的 numPr
+		first string // 列表项的第一块内容
+	}{
+		{
+			"nested list",
+			listItem(parse.O_LIST, "1", listItem(parse.U_LIST, "", text("子项"))),
+			`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr>`, "子项",
+		},
+		{
+			"table",
+			listItem(parse.O_LIST, "1", table, text("说明")),
+			`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr>`, "<w:tbl>",
+		},
+		{
+			"header",
+			listItem(parse.U_LIST, "", header("3", "小标题"), text("正文")),
+			`<w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr>`, "小标题",
+		},
+	}
+	for _, tt := range tests {
+		article := parse.Article{Title: header("1", "列表"), Content: []parse.Piece{tt.item}}
+		out, _, err := docxRenderer{}.Render(article, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := unzipFiles(t, out)["word/document.xml"]
+		if n := strings.Count(doc, tt.numPr); n != 1 {
+			t.Errorf("%s: %d paragraphs carry the item number, want 1\n%s", tt.name, n, doc)
+			continue
+		}
+		if strings.Index(doc, tt.numPr) > strings.Index(doc, tt.first) {
+			t.Errorf("%s: item number comes after its content\n%s", tt.name, doc)
+		}
+	}
+}
+
+func TestRenderDocx(t *testing.T) {
+	renderGolden(t, "docx")
+}
@@ -0,0 +1,369 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// epubRenderer 把单篇文章输出成只有一章的 EPUB 3 电子书，图片打包在书内
+type epubRenderer struct{}
+
+func (epubRenderer) Render(article parse.Article, opts Options) ([]byte, map[string][]byte, error) {
+	title, _ := article.Title.Val.(string)
+	book, err := RenderEpub(strings.TrimSpace(title), []parse.Article{article}, opts)
+	return book, nil, err
+}
+
+func (epubRenderer) Ext() string {
+	return "epub"
+}
+
+// epub 包内的固定文件
+const (
+	epubContainerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+	epubCSS = `body { line-height: 1.6; }
+img { max-width: 100%; height: auto; }
+pre { white-space: pre-wrap; word-wrap: break-word; }
+blockquote { margin-left: 1em; padding-left: 0.8em; border-left: 3px solid #ccc; color: #555; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.4em; }
+.meta { color: #888; font-size: 0.9em; }
+`
+)
+
+// tocEntry 目录中的一项，children 为下一级标题
+type tocEntry struct {
+	title    string
+	href     string
+	level    int
+	children []*tocEntry
+}
+
+// RenderEpub 把多篇文章按顺序作为章节输出成一本 EPUB 3 电子书，
+// 目录的第一级为各篇文章，其下为文章中的小标题
+func RenderEpub(title string, articles []parse.Article, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// mimetype 必须是第一个文件，并且不能压缩
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write([]byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		"META-INF/container.xml": []byte(epubContainerXML),
+		"OEBPS/style.css":        []byte(epubCSS),
+	}
+	images := make(map[string][]byte)
+	var toc []*tocEntry
+	var chapters []string
+	for i, article := range articles {
+		chapterName := fmt.Sprintf("chapter-%03d.xhtml", i+1)
+		chapterTitle, _ := article.Title.Val.(string)
+		chapterTitle = strings.TrimSpace(chapterTitle)
+		entry := &tocEntry{title: chapterTitle, href: chapterName, level: 1}
+
+		pieces := epubPieces(article.Content, chapterName, entry, new(int))
+		content, saveImageBytes := formatHTMLBlocks(pieces)
+		mergeMap(images, saveImageBytes)
+
+		var meta []string
+		if article.Metadata.AccountName != "" {
+			meta = append(meta, html.EscapeString(article.Metadata.AccountName))
+		}
+		if !article.Metadata.PublishTime.IsZero() {
+			meta = append(meta, article.Metadata.PublishTime.Format("2006-01-02 15:04"))
+		}
+		var body string = "<h1>" + html.EscapeString(chapterTitle) + "</h1>\n"
+		if len(meta) > 0 {
+			body += "<p class=\"meta\">" + strings.Join(meta, " · ") + "</p>\n"
+		}
+		body += content
+		for _, link := range sourceLinks(article.Metadata) {
+			body += "<p class=\"meta\">" + html.EscapeString(link.label) + "：" + formatHTMLSourceLink(link.text, link.url) + "</p>\n"
+		}
+		files["OEBPS/"+chapterName] = []byte(epubXHTML(chapterTitle, body))
+		chapters = append(chapters, chapterName)
+		toc = append(toc, entry)
+	}
+	for name, content := range images {
+		files["OEBPS/"+name] = content
+	}
+	identifier := epubIdentifier(title, articles)
+	files["OEBPS/nav.xhtml"] = []byte(epubXHTML(title, "<nav epub:type=\"toc\" id=\"toc\">\n<h1>目录</h1>\n"+formatNavList(toc)+"</nav>\n"))
+	files["OEBPS/toc.ncx"] = []byte(formatNCX(title, identifier, toc))
+	files["OEBPS/content.opf"] = []byte(formatOPF(title, identifier, articles, chapters, images))
+
+	// 按固定顺序写入，保证同样的输入得到同样的文件
+	names := []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx", "OEBPS/style.css"}
+	for _, chapter := range chapters {
+		names = append(names, "OEBPS/"+chapter)
+	}
+	for _, name := range sortedKeys(images) {
+		names = append(names, "OEBPS/"+name)
+	}
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// epubPieces 让 pieces 适合放进 epub：base64 图片解码后打包进书内，只有链接的图片和视频、音频、卡片改为链接（电子书里不能引用网络资源），
+// 并给小标题加上 id，同时记到章节的目录下
+func epubPieces(pieces []parse.Piece, chapterName string, entry *tocEntry, headerCount *int) []parse.Piece {
+	var res []parse.Piece
+	for _, piece := range pieces {
+		switch piece.Type {
+		case parse.HEADER:
+			*headerCount++
+			id := "h" + strconv.Itoa(*headerCount)
+			attrs := make(map[string]string)
+			for k, v := range piece.Attrs {
+				attrs[k] = v
+			}
+			attrs["id"] = id
+			piece.Attrs = attrs
+			level, _ := strconv.Atoi(attrs["level"])
+			addTocEntry(entry, &tocEntry{title: strings.TrimSpace(piece.Val.(string)), href: chapterName + "#" + id, level: level})
+		case parse.IMAGE_BASE64:
+			data, err := base64.StdEncoding.DecodeString(piece.Val.(string))
+			if err != nil {
+				continue
+			}
+			piece = parse.Piece{Type: parse.IMAGE, Val: data, Attrs: piece.Attrs}
+		case parse.IMAGE:
+			if piece.Val == nil {
+				alt := piece.Attrs["alt"]
+				if alt == "" {
+					alt = "图片"
+				}
+				piece = parse.Piece{Type: parse.LINK, Val: alt, Attrs: map[string]string{"href": piece.Attrs["src"]}}
+			}
+		case parse.VIDEO, parse.AUDIO, parse.EMBED_CARD:
+			piece = epubMediaLink(piece)
+		case parse.BLOCK_QUOTES, parse.O_LIST, parse.U_LIST:
+			piece.Val = epubPieces(piece.Val.([]parse.Piece), chapterName, entry, headerCount)
+		case parse.TABLE:
+			if table, ok := piece.Val.(parse.Table); ok {
+				rows := make([]parse.TableRow, len(table.Rows))
+				for i, row := range table.Rows {
+					rows[i] = make(parse.TableRow, len(row))
+					for j, cell := range row {
+						cell.Content = epubPieces(cell.Content, chapterName, entry, headerCount)
+						rows[i][j] = cell
+					}
+				}
+				table.Rows = rows
+				piece.Val = table
+			}
+		}
+		res = append(res, piece)
+	}
+	return res
+}
+
+// epubMediaLink 电子书里不能播放网络上的视频、音频，也不能引用封面等网络图片，改为指向原地址的链接，没有地址时只留标题
+func epubMediaLink(piece parse.Piece) parse.Piece {
+	var title, href string
+	switch piece.Type {
+	case parse.VIDEO:
+		title = mediaTitle(piece, "视频")
+		href = firstNonEmpty(piece.Attrs["src"], piece.Attrs["file"], piece.Attrs["embed"])
+	case parse.AUDIO:
+		title = mediaTitle(piece, "音频")
+		href = piece.Attrs["src"]
+	default:
+		href = piece.Attrs["url"]
+		title = firstNonEmpty(piece.Attrs["title"], href)
+	}
+	if href == "" {
+		return parse.Piece{Type: parse.NORMAL_TEXT, Val: title}
+	}
+	return parse.Piece{Type: parse.LINK, Val: title, Attrs: map[string]string{"href": href}}
+}
+
+// addTocEntry 把小标题挂到目录中比它级别高的最近一项下面
+func addTocEntry(parent *tocEntry, entry *tocEntry) {
+	if entry.level <= parent.level {
+		entry.level = parent.level + 1
+	}
+	for len(parent.children) > 0 {
+		last := parent.children[len(parent.children)-1]
+		if last.level >= entry.level {
+			break
+		}
+		parent = last
+	}
+	parent.children = append(parent.children, entry)
+}
+
+func formatNavList(entries []*tocEntry) string {
+	navStr := "<ol>\n"
+	for _, entry := range entries {
+		navStr += "<li><a href=\"" + html.EscapeString(entry.href) + "\">" + html.EscapeString(entry.title) + "</a>"
+		if len(entry.children) > 0 {
+			navStr += "\n" + formatNavList(entry.children)
+		}
+		navStr += "</li>\n"
+	}
+	return navStr + "</ol>\n"
+}
+
+// formatNCX EPUB 2 的目录，给不认 nav.xhtml 的旧阅读器用
+func formatNCX(title string, identifier string, toc []*tocEntry) string {
+	playOrder := 0
+	var navPoints func(entries []*tocEntry) string
+	navPoints = func(entries []*tocEntry) string {
+		var ncxStr string
+		for _, entry := range entries {
+			playOrder++
+			ncxStr += "<navPoint id=\"nav-" + strconv.Itoa(playOrder) + "\" playOrder=\"" + strconv.Itoa(playOrder) + "\">" +
+				"<navLabel><text>" + html.EscapeString(entry.title) + "</text></navLabel>" +
+				"<content src=\"" + html.EscapeString(entry.href) + "\"/>\n" +
+				navPoints(entry.children) + "</navPoint>\n"
+		}
+		return ncxStr
+	}
+	points := navPoints(toc)
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head>
+<meta name="dtb:uid" content="` + identifier + `"/>
+</head>
+<docTitle><text>` + html.EscapeString(title) + `</text></docTitle>
+<navMap>
+` + points + `</navMap>
+</ncx>
+`
+}
+
+func formatOPF(title string, identifier string, articles []parse.Article, chapters []string, images map[string][]byte) string {
+	var creators []string
+	var modified time.Time
+	for _, article := range articles {
+		creator := firstNonEmpty(article.Metadata.AccountName, article.Metadata.Author)
+		if creator != "" && !containsString(creators, creator) {
+			creators = append(creators, creator)
+		}
+		if article.Metadata.PublishTime.After(modified) {
+			modified = article.Metadata.PublishTime
+		}
+	}
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	metadata := "<dc:identifier id=\"bookid\">" + identifier + "</dc:identifier>\n" +
+		"<dc:title>" + html.EscapeString(title) + "</dc:title>\n" +
+		"<dc:language>zh-CN</dc:language>\n"
+	for _, creator := range creators {
+		metadata += "<dc:creator>" + html.EscapeString(creator) + "</dc:creator>\n"
+	}
+	metadata += "<meta property=\"dcterms:modified\">" + modified.UTC().Format("2006-01-02T15:04:05Z") + "</meta>\n"
+
+	manifest := "<item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n" +
+		"<item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>\n" +
+		"<item id=\"css\" href=\"style.css\" media-type=\"text/css\"/>\n"
+	var spine string
+	for i, chapter := range chapters {
+		id := "chapter-" + strconv.Itoa(i+1)
+		manifest += "<item id=\"" + id + "\" href=\"" + chapter + "\" media-type=\"application/xhtml+xml\"/>\n"
+		spine += "<itemref idref=\"" + id + "\"/>\n"
+	}
+	for i, name := range sortedKeys(images) {
+		manifest += "<item id=\"img-" + strconv.Itoa(i+1) + "\" href=\"" + html.EscapeString(name) + "\" media-type=\"" + imageMediaType(name, images[name]) + "\"/>\n"
+	}
+
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid" xml:lang="zh-CN">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+` + metadata + `</metadata>
+<manifest>
+` + manifest + `</manifest>
+<spine toc="ncx">
+` + spine + `</spine>
+</package>
+`
+}
+
+func epubXHTML(title string, body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="zh-CN" lang="zh-CN">
+<head>
+<meta charset="UTF-8"/>
+<title>` + html.EscapeString(title) + `</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+` + xmlCharsOnly(body) + `
+</body>
+</html>
+`
+}
+
+// xmlCharsOnly 去掉 XML 中不允许出现的控制字符，否则阅读器会解析失败
+func xmlCharsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != 0xFFFE && r != 0xFFFF) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// epubIdentifier 由文章链接（或标题）生成固定的 urn:uuid，同一批文章每次生成的书相同
+func epubIdentifier(title string, articles []parse.Article) string {
+	seed := title
+	for _, article := range articles {
+		articleTitle, _ := article.Title.Val.(string)
+		seed += "\n" + firstNonEmpty(article.Metadata.URL, articleTitle)
+	}
+	h := md5Hex([]byte(seed))
+	return "urn:uuid:" + h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// imageMediaType 优先按图片内容判断类型，判断不出时按扩展名
+func imageMediaType(name string, content []byte) string {
+	if mediaType := parse.DetectImageType(content); mediaType != "" {
+		return mediaType
+	}
+	ext := strings.ToLower(name[strings.LastIndex(name, ".")+1:])
+	switch ext {
+	case "svg":
+		return "image/svg+xml"
+	case "jpg", "jpeg":
+		return "image/jpeg"
+	case "gif", "webp":
+		return "image/" + ext
+	default:
+		return "image/png"
+	}
+}
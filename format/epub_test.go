@@ -0,0 +1,74 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestRenderEpub(t *testing.T) {
+	renderGolden(t, "epub")
+}
+
+func TestRenderEpubArchive(t *testing.T) {
+	second := parse.Article{
+		Title: header("1", "第二篇"),
+		Metadata: parse.Metadata{
+			AccountName: "示例公众号",
+			URL:         "https://mp.weixin.qq.com/s/second",
+			PublishTime: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Content: []parse.Piece{
+			header("2", "小节"),
+			text("正文"),
+			{Type: parse.IMAGE, Val: pngData(t), Attrs: map[string]string{"src": "https://mmbiz.qpic.cn/b.png", "alt": "图"}},
+		},
+	}
+	out, err := RenderEpub("示例公众号", []parse.Article{sampleArticle(), second}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "render/archive.epub.txt", zipDump(t, out))
+}
+
+// 电子书里不能引用网络资源：视频、音频、卡片和没有下载的图片都只能是链接
+func TestEpubNoRemoteResources(t *testing.T) {
+	article := parse.Article{
+		Title: header("1", "媒体"),
+		Content: []parse.Piece{
+			{Type: parse.VIDEO, Attrs: map[string]string{"src": "https://v.qq.com/x/page/a.html", "embed": "https://v.qq.com/txp/iframe/player.html?vid=a",
+				"poster": "https://mmbiz.qpic.cn/p.jpg", "title": "演示"}},
+			{Type: parse.VIDEO, Attrs: map[string]string{"file": "https://a.com/v.mp4", "poster": "https://mmbiz.qpic.cn/p2.jpg"}},
+			{Type: parse.VIDEO, Attrs: map[string]string{"provider": "channels", "poster": "https://mmbiz.qpic.cn/p3.jpg", "title": "视频号"}},
+			{Type: parse.AUDIO, Attrs: map[string]string{"src": "https://a.com/a.mp3", "title": "歌"}},
+			{Type: parse.EMBED_CARD, Attrs: map[string]string{"kind": "miniprogram", "title": "小程序名", "url": "https://a.com/m", "image": "https://mmbiz.qpic.cn/c.jpg"}},
+			{Type: parse.IMAGE, Attrs: map[string]string{"src": "https://mmbiz.qpic.cn/i.png", "alt": "图"}},
+		},
+	}
+	out, _, err := epubRenderer{}.Render(article, Options{MediaStyle: MEDIA_STYLE_HTML5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := unzipFiles(t, out)
+	remote := regexp.MustCompile(`(?:src|poster|data)="https?://`)
+	for name, content := range files {
+		if m := remote.FindString(content); m != "" {
+			t.Errorf("%s references a remote resource: %s", name, m)
+		}
+		if strings.Contains(content, "<iframe") || strings.Contains(content, "<video") || strings.Contains(content, "<audio") {
+			t.Errorf("%s embeds a player", name)
+		}
+	}
+	if regexp.MustCompile(`<item [^>]*href="https?://`).MatchString(files["OEBPS/content.opf"]) {
+		t.Errorf("content.opf references a remote item:\n%s", files["OEBPS/content.opf"])
+	}
+	chapter := files["OEBPS/chapter-001.xhtml"]
+	for _, want := range []string{`<a href="https://v.qq.com/x/page/a.html">演示</a>`, "视频号", `<a href="https://a.com/a.mp3">歌</a>`, `<a href="https://a.com/m">小程序名</a>`} {
+		if !strings.Contains(chapter, want) {
+			t.Errorf("chapter lacks %s:\n%s", want, chapter)
+		}
+	}
+}
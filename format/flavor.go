@@ -0,0 +1,402 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Flavor 输出 Markdown 的方言，按目标工具整体切换换行、图片、表格、高亮、引用和 front matter 的写法
+type Flavor int32
+
+const (
+	FLAVOR_DEFAULT    Flavor = iota // 原有的输出：两个空格换行，base64 图片用引用式链接
+	FLAVOR_COMMONMARK               // CommonMark：反斜杠换行，表格一律输出 html
+	FLAVOR_GFM                      // GitHub Flavored Markdown
+	FLAVOR_OBSIDIAN                 // Obsidian：本地图片用 ![[name]]，==高亮==，引用为 callout
+	FLAVOR_TYPORA                   // Typora：==高亮==
+	FLAVOR_HEXO                     // Hexo：本地图片用 asset_img 标签，引用为 blockquote 标签
+	FLAVOR_LOGSEQ                   // Logseq：大纲块，^^高亮^^，page properties，表格只用管道表格
+)
+
+func FlavorArgValue2Flavor(val string) Flavor {
+	switch strings.ToLower(val) {
+	case "commonmark":
+		return FLAVOR_COMMONMARK
+	case "gfm", "github":
+		return FLAVOR_GFM
+	case "obsidian":
+		return FLAVOR_OBSIDIAN
+	case "typora":
+		return FLAVOR_TYPORA
+	case "hexo":
+		return FLAVOR_HEXO
+	case "logseq":
+		return FLAVOR_LOGSEQ
+	default:
+		return FLAVOR_DEFAULT
+	}
+}
+
+// DefaultFrontMatter 该方言默认的 front matter 格式，未指定 --front-matter 时使用
+func (f Flavor) DefaultFrontMatter() FrontMatterStyle {
+	return flavorProfiles[f].frontMatter
+}
+
+type tableSyntax int32
+
+const (
+	TABLE_PIPE_OR_HTML tableSyntax = iota // 能用管道表格时用管道表格，否则用 html 表格
+	TABLE_HTML                            // 一律用 html 表格
+	TABLE_PIPE                            // 合并的单元格拆开留空，尽量用管道表格
+)
+
+type imageSyntax int32
+
+const (
+	IMAGE_SYNTAX_MARKDOWN  imageSyntax = iota // ![alt](name)
+	IMAGE_SYNTAX_WIKILINK                     // ![[name]]
+	IMAGE_SYNTAX_ASSET_IMG                    // {% asset_img name alt %}
+)
+
+// flavorProfile 一种方言下各元素的写法
+type flavorProfile struct {
+	// lineBreak 段落内的换行
+	lineBreak string
+	// blockEnd 标题、代码块等块级元素的结尾
+	blockEnd string
+	// linkBreak 链接后面是否换行（原有的输出如此）
+	linkBreak bool
+	// localImage 保存到本地的图片的写法
+	localImage imageSyntax
+	// base64Refer base64 图片是否用引用式链接，放在文末
+	base64Refer bool
+	table       tableSyntax
+	// highlight、strike 高亮和删除线的前后标记
+	highlight [2]string
+	strike    [2]string
+	// quoteOpen、quoteClose 引用块的包裹方式，为空时用 "> "；quoteCallout 为 "> " 引用的第一行
+	quoteOpen    string
+	quoteClose   string
+	quoteCallout string
+	// cardCallout 小程序、公众号名片等卡片引用的第一行
+	cardCallout string
+	// frontMatter 默认的 front matter 格式，frontMatterFields 默认的字段及字段名，dateLayout 日期格式
+	frontMatter       FrontMatterStyle
+	frontMatterFields []FrontMatterField
+	dateLayout        string
+	// outline 正文是否输出为大纲，每个段落、标题、列表项等都是一个 "- " 块
+	outline bool
+}
+
+var flavorProfiles = map[Flavor]flavorProfile{
+	FLAVOR_DEFAULT: {
+		lineBreak:   "  \n",
+		blockEnd:    "  \n",
+		linkBreak:   true,
+		base64Refer: true,
+		highlight:   [2]string{"<mark>", "</mark>"},
+		strike:      [2]string{"~~", "~~"},
+	},
+	FLAVOR_COMMONMARK: {
+		lineBreak: "\\\n",
+		blockEnd:  "\n",
+		table:     TABLE_HTML,
+		highlight: [2]string{"<mark>", "</mark>"},
+		strike:    [2]string{"<del>", "</del>"},
+	},
+	FLAVOR_GFM: {
+		lineBreak:   "\\\n",
+		blockEnd:    "\n",
+		highlight:   [2]string{"<mark>", "</mark>"},
+		strike:      [2]string{"~~", "~~"},
+		cardCallout: "[!NOTE]",
+	},
+	FLAVOR_OBSIDIAN: {
+		lineBreak:    "\n",
+		blockEnd:     "\n",
+		localImage:   IMAGE_SYNTAX_WIKILINK,
+		highlight:    [2]string{"==", "=="},
+		strike:       [2]string{"~~", "~~"},
+		quoteCallout: "[!quote]",
+		cardCallout:  "[!info]",
+		frontMatter:  FRONT_MATTER_YAML,
+		// 与 Obsidian Web Clipper 的属性名一致
+		frontMatterFields: []FrontMatterField{
+			{FM_TITLE, "title"},
+			{FM_URL, "source"},
+			{FM_AUTHOR, "author"},
+			{FM_DATE, "published"},
+			{FM_DIGEST, "description"},
+			{FM_TAGS, "tags"},
+		},
+		dateLayout: "2006-01-02",
+	},
+	FLAVOR_TYPORA: {
+		lineBreak: "  \n",
+		blockEnd:  "\n",
+		highlight: [2]string{"==", "=="},
+		strike:    [2]string{"~~", "~~"},
+	},
+	FLAVOR_HEXO: {
+		lineBreak:   "\n",
+		blockEnd:    "\n",
+		localImage:  IMAGE_SYNTAX_ASSET_IMG,
+		highlight:   [2]string{"<mark>", "</mark>"},
+		strike:      [2]string{"~~", "~~"},
+		quoteOpen:   "{% blockquote %}",
+		quoteClose:  "{% endblockquote %}",
+		frontMatter: FRONT_MATTER_YAML,
+		frontMatterFields: []FrontMatterField{
+			{FM_TITLE, "title"},
+			{FM_DATE, "date"},
+			{FM_TAGS, "tags"},
+			{FM_ACCOUNT, "categories"},
+			{FM_AUTHOR, "author"},
+			{FM_COVER, "cover"},
+			{FM_DIGEST, "description"},
+		},
+		dateLayout: "2006-01-02 15:04:05",
+	},
+	FLAVOR_LOGSEQ: {
+		lineBreak:   "\n",
+		blockEnd:    "\n",
+		table:       TABLE_PIPE,
+		highlight:   [2]string{"^^", "^^"},
+		strike:      [2]string{"~~", "~~"},
+		quoteOpen:   "#+BEGIN_QUOTE",
+		quoteClose:  "#+END_QUOTE",
+		frontMatter: FRONT_MATTER_PROPERTIES,
+		frontMatterFields: []FrontMatterField{
+			{FM_TITLE, "title"},
+			{FM_AUTHOR, "author"},
+			{FM_DATE, "date"},
+			{FM_TAGS, "tags"},
+			{FM_URL, "source"},
+		},
+		dateLayout: "2006-01-02",
+		outline:    true,
+	},
+}
+
+// profile 未知的方言按原有的输出处理
+func (opts *Options) profile() flavorProfile {
+	if p, ok := flavorProfiles[opts.Flavor]; ok {
+		return p
+	}
+	return flavorProfiles[FLAVOR_DEFAULT]
+}
+
+// formatLocalImage 保存到本地的图片
+func (p flavorProfile) formatLocalImage(alt string, name string) string {
+	switch p.localImage {
+	case IMAGE_SYNTAX_WIKILINK:
+		return "![[" + name + "]]"
+	case IMAGE_SYNTAX_ASSET_IMG:
+		if alt = strings.TrimSpace(alt); alt != "" {
+			return "{% asset_img " + name + " \"" + strings.ReplaceAll(alt, "\"", "'") + "\" %}"
+		}
+		return "{% asset_img " + name + " %}"
+	default:
+		return "![" + alt + "](" + name + ")"
+	}
+}
+
+// formatQuote 给已经输出好的引用内容加上引用的写法
+func (p flavorProfile) formatQuote(content string, callout string) string {
+	content = strings.TrimRight(content, " \n")
+	if p.quoteOpen != "" {
+		return p.quoteOpen + "\n" + content + "\n" + p.quoteClose + p.blockEnd
+	}
+	var lines []string
+	if callout != "" {
+		lines = append(lines, "> "+callout)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, ">")
+		} else {
+			lines = append(lines, "> "+line)
+		}
+	}
+	return strings.Join(lines, "\n") + p.blockEnd
+}
+
+var (
+	headingReg  = regexp.MustCompile(`^#{1,6}(\s|$)`)
+	listItemReg = regexp.MustCompile(`^( *)([-+*]|\d{1,9}[.)]) `)
+)
+
+// outlineBlock 大纲中的一个块，lines 为去掉缩进后的各行
+type outlineBlock struct {
+	depth int
+	lines []string
+}
+
+// formatOutline 把输出好的 Markdown 转成大纲：空行分开的段落、标题、代码块、表格、引用和每个列表项各是一个 "- " 块，
+// 标题下的内容是它的子块，嵌套的列表项是上一级列表项的子块；无序列表的标记由块的 "- " 代替，有序列表保留序号
+func formatOutline(md string) string {
+	var blocks []outlineBlock
+	var headings []int // 所在的各级标题的级别
+	var items []int    // 所在的各级列表项内容的起始列
+	cur := -1          // 正在输出的块，-1 表示下一行开始新的块
+	var fence string
+	var inQuote bool
+	newBlock := func(depth int, line string) {
+		blocks = append(blocks, outlineBlock{depth, []string{line}})
+		cur = len(blocks) - 1
+	}
+	column := func() int {
+		if len(items) == 0 {
+			return 0
+		}
+		return items[len(items)-1]
+	}
+	for _, line := range strings.Split(md, "\n") {
+		if fence != "" || inQuote {
+			line = trimIndent(line, column())
+			blocks[cur].lines = append(blocks[cur].lines, line)
+			rest := strings.TrimSpace(line)
+			if fence != "" && strings.HasPrefix(rest, fence) && strings.Trim(rest, fence[:1]) == "" {
+				fence = ""
+			}
+			if inQuote && rest == "#+END_QUOTE" {
+				inQuote = false
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			cur = -1
+			continue
+		}
+		if headingReg.MatchString(line) {
+			level := len(line) - len(strings.TrimLeft(line, "#"))
+			for len(headings) > 0 && headings[len(headings)-1] >= level {
+				headings = headings[:len(headings)-1]
+			}
+			items = nil
+			newBlock(len(headings), strings.TrimSpace(line))
+			headings = append(headings, level)
+			cur = -1
+			continue
+		}
+		if m := listItemReg.FindStringSubmatch(line); m != nil {
+			indent := len(m[1])
+			for len(items) > 0 && items[len(items)-1] > indent {
+				items = items[:len(items)-1]
+			}
+			text := line[len(m[0]):]
+			if !strings.ContainsAny(m[2], "-+*") {
+				text = m[2] + " " + text
+			}
+			newBlock(len(headings)+len(items), text)
+			items = append(items, indent+len(m[2])+1)
+		} else {
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			if cur == -1 {
+				for len(items) > 0 && items[len(items)-1] > indent {
+					items = items[:len(items)-1]
+				}
+				newBlock(len(headings)+len(items), trimIndent(line, column()))
+			} else {
+				blocks[cur].lines = append(blocks[cur].lines, trimIndent(line, column()))
+			}
+		}
+		rest := blocks[cur].lines[len(blocks[cur].lines)-1]
+		if m := fenceReg.FindString(strings.TrimSpace(rest)); m != "" {
+			fence = m
+		} else if strings.TrimSpace(rest) == "#+BEGIN_QUOTE" {
+			inQuote = true
+		}
+	}
+	var b strings.Builder
+	for _, block := range blocks {
+		indent := strings.Repeat("\t", block.depth)
+		for len(block.lines) > 1 && strings.TrimSpace(block.lines[len(block.lines)-1]) == "" {
+			block.lines = block.lines[:len(block.lines)-1]
+		}
+		for i, line := range block.lines {
+			if i == 0 {
+				b.WriteString(indent + "- " + line + "\n")
+			} else {
+				b.WriteString(indent + "  " + line + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// trimIndent 去掉行首至多 n 个空格的缩进
+func trimIndent(line string, n int) string {
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return line[i:]
+}
+
+var fenceReg = regexp.MustCompile("^(`{3,}|~{3,})")
+
+// blockStartReg 能打断段落的块级元素的开头
+var blockStartReg = regexp.MustCompile(`^(#{1,6}(\s|$)|[-+*]\s|\d{1,9}[.)]\s|` + "```" + `|~~~|<|\{%|#\+)`)
+
+// trimBackslashBreaks 反斜杠换行后面没有同一段落的内容时，反斜杠会原样显示，把它们去掉；
+// 只有反斜杠的行当作空行。代码块内的行不处理
+func trimBackslashBreaks(md string) string {
+	lines := strings.Split(md, "\n")
+	inCode := make([]bool, len(lines))
+	var fence string
+	for i, line := range lines {
+		_, rest := splitQuotePrefix(line)
+		rest = strings.TrimSpace(rest)
+		if fence != "" {
+			inCode[i] = true
+			if strings.HasPrefix(rest, fence) && strings.Trim(rest, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if m := fenceReg.FindString(rest); m != "" {
+			fence = m
+			inCode[i] = true
+			continue
+		}
+		if rest == "\\" {
+			prefix, _ := splitQuotePrefix(line)
+			lines[i] = strings.TrimRight(prefix, " ")
+		}
+	}
+	for i, line := range lines {
+		if inCode[i] || !strings.HasSuffix(line, "\\") || strings.HasSuffix(line, "\\\\") {
+			continue
+		}
+		prefix, _ := splitQuotePrefix(line)
+		var next string
+		var nextPrefix string
+		if i+1 < len(lines) {
+			nextPrefix, next = splitQuotePrefix(lines[i+1])
+		}
+		next = strings.TrimSpace(next)
+		if i+1 == len(lines) || next == "" || strings.TrimSpace(nextPrefix) != strings.TrimSpace(prefix) || blockStartReg.MatchString(next) {
+			lines[i] = strings.TrimSuffix(line, "\\")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitQuotePrefix 分出行首的 "> " 引用前缀
+func splitQuotePrefix(line string) (string, string) {
+	i := 0
+	for i < len(line) {
+		switch {
+		case line[i] == '>':
+			i++
+		case line[i] == ' ' && i+1 < len(line) && line[i+1] == '>':
+			i++
+		case line[i] == ' ' && i > 0 && line[i-1] == '>':
+			i++
+		default:
+			return line[:i], line[i:]
+		}
+	}
+	return line, ""
+}
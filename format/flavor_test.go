@@ -0,0 +1,72 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestFlavorGolden(t *testing.T) {
+	flavors := []string{"default", "commonmark", "gfm", "obsidian", "typora", "hexo", "logseq"}
+	for _, name := range flavors {
+		t.Run(name, func(t *testing.T) {
+			flavor := FlavorArgValue2Flavor(name)
+			md, _ := FormatWithOptions(sampleArticle(), Options{Flavor: flavor, FrontMatter: flavor.DefaultFrontMatter()})
+			checkGolden(t, "flavor/"+name+".md", []byte(md))
+		})
+	}
+}
+
+func TestFlavorHighlight(t *testing.T) {
+	tests := []struct {
+		flavor string
+		want   string
+	}{
+		{"default", "<mark>高亮</mark>"},
+		{"commonmark", "<mark>高亮</mark>"},
+		{"obsidian", "==高亮=="},
+		{"typora", "==高亮=="},
+		{"logseq", "^^高亮^^"},
+	}
+	piece := parse.Piece{Type: parse.HIGHLIGHT_TEXT, Val: "高亮"}
+	for _, tt := range tests {
+		opts := Options{Flavor: FlavorArgValue2Flavor(tt.flavor)}
+		if got := formatInlineText(piece, &opts); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.flavor, got, tt.want)
+		}
+	}
+}
+
+func TestFormatOutline(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			"paragraphs under headings",
+			"# 标题\n第一段\n第二行\n\n## 小节\n正文\n# 另一个标题\n",
+			"- # 标题\n\t- 第一段\n\t  第二行\n\t- ## 小节\n\t\t- 正文\n- # 另一个标题\n",
+		},
+		{
+			"nested and ordered lists",
+			"- a\n  - b\n- c\n\n1. 一\n2. 二\n\n后文\n",
+			"- a\n\t- b\n- c\n- 1. 一\n- 2. 二\n- 后文\n",
+		},
+		{
+			"code block keeps blank lines and markers",
+			"```\n- x\n\n# y\n```\n",
+			"- ```\n  - x\n  \n  # y\n  ```\n",
+		},
+		{
+			"quote with blank lines",
+			"#+BEGIN_QUOTE\n甲\n\n乙\n#+END_QUOTE\n",
+			"- #+BEGIN_QUOTE\n  甲\n  \n  乙\n  #+END_QUOTE\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := formatOutline(tt.md); got != tt.want {
+			t.Errorf("%s: formatOutline(%q) =\n%q\nwant\n%q", tt.name, tt.md, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,8 @@
 package format
 
 import (
-	"bytes"
-	"encoding/binary"
-	"log"
+	"encoding/base64"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -12,18 +11,66 @@ import (
 	"strings"
 
 	"github.com/fengxxc/wechatmp2markdown/parse"
-	"github.com/fengxxc/wechatmp2markdown/util"
 )
 
+// Options 输出选项
+type Options struct {
+	// MediaStyle 视频、音频的输出方式
+	MediaStyle MediaStyle
+	// FrontMatter 文件开头的 front matter 格式，默认不输出
+	FrontMatter FrontMatterStyle
+	// FrontMatterFields front matter 中输出哪些字段及字段名，为空时输出方言默认的字段或 DefaultFrontMatterFields
+	FrontMatterFields []FrontMatterField
+	// Flavor Markdown 的方言，默认为原有的输出
+	Flavor Flavor
+}
+
+type MediaStyle int32
+
+const (
+	MEDIA_STYLE_LINK  MediaStyle = iota // 带封面的链接
+	MEDIA_STYLE_HTML5                   // html5 的 video/audio/iframe 标签
+)
+
+func MediaArgValue2MediaStyle(val string) MediaStyle {
+	if val == "html5" {
+		return MEDIA_STYLE_HTML5
+	}
+	return MEDIA_STYLE_LINK
+}
+
 // Format format article
 func Format(article parse.Article) (string, map[string][]byte) {
+	return FormatWithOptions(article, Options{})
+}
+
+// FormatWithOptions format article with options
+func FormatWithOptions(article parse.Article, opts Options) (string, map[string][]byte) {
 	var result string
-	var titleMdStr string = formatTitle(article.Title)
-	result += titleMdStr
+	// 没有标题的文章不输出空的标题行
+	if title, _ := article.Title.Val.(string); strings.TrimSpace(title) != "" {
+		result = formatTitle(article.Title) + opts.profile().blockEnd
+	}
 	var saveImageBytes map[string][]byte
-	content, saveImageBytes := formatContent(article.Content, 0)
+	content, saveImageBytes := formatContent(article.Content, 0, &opts)
 	result += content
-	return result, saveImageBytes
+	if links := sourceLinks(article.Metadata); len(links) > 0 {
+		result += "\n"
+		for _, link := range links {
+			if link.url == "" {
+				result += link.label + "：" + link.text + opts.profile().lineBreak
+			} else {
+				result += link.label + "：[" + link.text + "](" + link.url + ")" + opts.profile().lineBreak
+			}
+		}
+	}
+	if opts.profile().lineBreak == "\\\n" {
+		result = trimBackslashBreaks(result)
+	}
+	if opts.profile().outline {
+		result = formatOutline(result)
+	}
+	return formatFrontMatter(article, &opts) + result, saveImageBytes
 }
 
 // windows下, 文件名包含非法字符时, 用相似的Unicode字符进行替换; 长度超过255个字符时，保留前255个字符
@@ -68,11 +115,21 @@ func legalizationFilenameForLinux(name string) string {
 
 // FormatAndSave fomat article and save to local file
 func FormatAndSave(article parse.Article, filePath string) error {
+	return FormatAndSaveWithOptions(article, filePath, Options{})
+}
+
+// FormatAndSaveWithOptions fomat article with options and save to local file
+func FormatAndSaveWithOptions(article parse.Article, filePath string, opts Options) error {
+	return RenderAndSave(article, filePath, markdownRenderer{}, opts)
+}
+
+// RenderAndSave 用 renderer 输出文章并保存到本地。filePath 以输出格式的扩展名结尾时直接作为文件名，
+// 否则视为目录，以文章标题建子目录保存；图片等附件保存在同一目录下
+func RenderAndSave(article parse.Article, filePath string, renderer Renderer, opts Options) error {
 	// basrPath := filepath.Join(filePath, )
 	var basePath string
 	var fileName string
 	var isWin bool = runtime.GOOS == "windows"
-	var isLinux bool = runtime.GOOS == "linux"
 	var separator string
 	if isWin {
 		separator = "\\"
@@ -86,60 +143,63 @@ func FormatAndSave(article parse.Article, filePath string) error {
 		wd, _ := os.Getwd()
 		filePath = strings.Replace(filePath, ".", wd, 1)
 	}
-	if strings.HasSuffix(filePath, ".md") {
-		// basePath = filePath[:len(filePath)-len(".md")]
-		basePath = filePath[:strings.LastIndex(filePath, separator)]
+	if strings.HasSuffix(strings.ToLower(filePath), "."+renderer.Ext()) {
+		basePath = filepath.Dir(filePath)
 		fileName = filePath
 	} else {
-		title := strings.TrimSpace(article.Title.Val.(string))
-		if isWin {
-			title = legalizationFilenameForWindows(title)
-		} else if isLinux {
-			title = legalizationFilenameForLinux(title)
-		}
-		// title := "thisistitle"
+		title := ArticleFileName(article)
 		basePath = filepath.Join(filePath, title)
-		fileName = filepath.Join(basePath, title+".md")
+		fileName = filepath.Join(basePath, title+"."+renderer.Ext())
 	}
 
 	// make basePath dir if not exists
 	if _, err := os.Stat(basePath); err != nil {
 		if err := os.MkdirAll(basePath, 0o755); err != nil {
-			panic(err)
+			return err
 		}
 	}
 
-	var saveImageBytes map[string][]byte
-	result, saveImageBytes := Format(article)
-	if len(saveImageBytes) > 0 {
-		for imgTitle := range saveImageBytes {
-			// save to local
-			imgfileName := filepath.Join(basePath, imgTitle)
-			/* if err := ioutil.WriteFile(imgfileName, saveImageBytes[imgTitle], 0644); err != nil {
-				log.Fatalf("can not save image file: %s\n err: %v", imgfileName, err)
-				continue
-			} */
-			f, err := os.Create(imgfileName)
-			if err != nil {
-				// log.Fatalf("can not save image file: %s", imgTitle)
-				log.Fatalf("can not save image file: %s\n err: %v", imgfileName, err)
-				continue
-			}
-			defer f.Close()
-			buf := new(bytes.Buffer)
-			err = binary.Write(buf, binary.LittleEndian, saveImageBytes[imgTitle])
-			if err != nil {
-				log.Fatalf("can not save image file: %s\n err: %v", imgfileName, err)
-				continue
-			}
-			_, err = f.Write(buf.Bytes())
-			if err != nil {
-				log.Fatalf("can not save image file: %s\n err: %v", imgfileName, err)
-				continue
-			}
+	result, saveFiles, err := renderer.Render(article, opts)
+	if err != nil {
+		return err
+	}
+	for name, content := range saveFiles {
+		// save to local
+		saveFileName := filepath.Join(basePath, name)
+		if err := os.WriteFile(saveFileName, content, 0o644); err != nil {
+			return fmt.Errorf("can not save file: %s\n err: %w", saveFileName, err)
 		}
 	}
-	return os.WriteFile(fileName, []byte(result), 0o644)
+	return os.WriteFile(fileName, result, 0o644)
+}
+
+// ArticleFileName 由文章标题得到合法的文件名（不含扩展名）；没有标题时依次用 公众号名_发布时间、文章的 mid，都没有时为 untitled
+func ArticleFileName(article parse.Article) string {
+	name, _ := article.Title.Val.(string)
+	name = strings.TrimSpace(name)
+	meta := article.Metadata
+	if name == "" && !meta.PublishTime.IsZero() {
+		name = strings.TrimSpace(meta.AccountName + "_" + meta.PublishTime.Format("2006-01-02_1504"))
+		name = strings.TrimPrefix(name, "_")
+	}
+	if name == "" && meta.Mid != "" {
+		name = meta.Mid + "_" + meta.Idx
+	}
+	if name == "" {
+		name = "untitled"
+	}
+	return LegalizationFilename(name)
+}
+
+// LegalizationFilename 按当前系统把标题中不能用于文件名的字符替换掉
+func LegalizationFilename(name string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return legalizationFilenameForWindows(name)
+	case "linux":
+		return legalizationFilenameForLinux(name)
+	}
+	return name
 }
 
 func formatTitle(piece parse.Piece) string {
@@ -148,7 +208,8 @@ func formatTitle(piece parse.Piece) string {
 	for i := 0; i < level; i++ {
 		prefix += "#"
 	}
-	return prefix + " " + piece.Val.(string) + "  \n"
+	title, _ := piece.Val.(string)
+	return prefix + " " + title
 }
 
 func formatMeta(meta []string) string {
@@ -159,137 +220,315 @@ func formatTags(tags string) string {
 	return tags + "  \n" // TODO
 }
 
-func formatContent(pieces []parse.Piece, depth int) (string, map[string][]byte) {
+func formatContent(pieces []parse.Piece, depth int, opts *Options) (string, map[string][]byte) {
 	var contentMdStr string
-	var base64Imgs []string
+	var base64Imgs []parse.Piece
 	var saveImageBytes map[string][]byte = make(map[string][]byte)
-	for _, piece := range pieces {
+	p := opts.profile()
+	for i, piece := range pieces {
 		var pieceMdStr string
 		var patchSaveImageBytes map[string][]byte
+		if isList(piece) && (i == 0 || !isList(pieces[i-1])) {
+			// 列表要从新的一行开始，顶层的列表和前文之间再空一行
+			if contentMdStr != "" && !strings.HasSuffix(contentMdStr, "\n") {
+				contentMdStr += "\n"
+			}
+			if depth == 0 || piece.Attrs["number"] != "" && piece.Attrs["number"] != "1" {
+				contentMdStr += "\n"
+			}
+		} else if !isList(piece) && i > 0 && isList(pieces[i-1]) && piece.Type != parse.BR {
+			// 列表结束后空一行，避免后面的文字被当成最后一项的延续
+			contentMdStr += "\n"
+		}
+		if piece.Type == parse.HEADER || piece.Type == parse.CODE_BLOCK || piece.Type == parse.EMBED_CARD || isDisplayMath(piece) {
+			contentMdStr = startNewLine(contentMdStr)
+		}
 		switch piece.Type {
 		case parse.HEADER:
-			pieceMdStr = formatTitle(piece)
+			pieceMdStr = formatTitle(piece) + p.blockEnd
 		case parse.LINK:
 			pieceMdStr = formatLink(piece)
+			if p.linkBreak {
+				pieceMdStr += p.lineBreak
+			}
 		case parse.NORMAL_TEXT:
 			pieceMdStr = piece.Val.(string)
-		case parse.BOLD_TEXT:
-			pieceMdStr = "**" + piece.Val.(string) + "**"
-		case parse.ITALIC_TEXT:
-			pieceMdStr = "*" + piece.Val.(string) + "*"
-		case parse.BOLD_ITALIC_TEXT:
-			pieceMdStr = "***" + piece.Val.(string) + "***"
+		case parse.BOLD_TEXT, parse.ITALIC_TEXT, parse.BOLD_ITALIC_TEXT,
+			parse.STRIKETHROUGH_TEXT, parse.UNDERLINE_TEXT, parse.HIGHLIGHT_TEXT, parse.SUP_TEXT, parse.SUB_TEXT,
+			parse.CODE_INLINE:
+			pieceMdStr = formatInlineText(piece, opts)
 		case parse.IMAGE:
 			if piece.Val == nil {
-				pieceMdStr = formatImageInline(piece)
+				pieceMdStr = formatImageInline(piece) + p.lineBreak
 			} else {
 				// will save to local
-				src := piece.Attrs["src"]
-				imgExt := util.ParseImageExtFromSrc(src)
-				var hashName string = util.MD5(piece.Val.([]byte)) + "." + imgExt
+				hashName := imageFileName(piece)
 				saveImageBytes[hashName] = piece.Val.([]byte)
-				pieceMdStr = formatImageFileReferInline(piece.Attrs["alt"], hashName)
+				pieceMdStr = p.formatLocalImage(piece.Attrs["alt"], hashName) + p.lineBreak
 			}
 		case parse.IMAGE_BASE64:
-			pieceMdStr = formatImageRefer(piece, len(base64Imgs))
-			base64Imgs = append(base64Imgs, piece.Val.(string))
+			if p.base64Refer {
+				pieceMdStr = formatImageRefer(piece, len(base64Imgs)) + p.lineBreak
+				base64Imgs = append(base64Imgs, piece)
+			} else {
+				pieceMdStr = formatImageBase64Inline(piece) + p.lineBreak
+			}
 		case parse.TABLE:
-			pieceMdStr = formatTable(piece)
-		case parse.CODE_INLINE:
-			// TODO
+			pieceMdStr, patchSaveImageBytes = formatTable(piece, opts)
 		case parse.CODE_BLOCK:
-			pieceMdStr = formatCodeBlock(piece)
+			pieceMdStr = formatCodeBlock(piece) + p.blockEnd
+		case parse.MATH:
+			pieceMdStr = formatMath(piece)
+			if isDisplayMath(piece) {
+				pieceMdStr += p.blockEnd
+			}
 		case parse.BLOCK_QUOTES:
-			pieceMdStr, patchSaveImageBytes = formatBlockQuote(piece, depth)
+			pieceMdStr, patchSaveImageBytes = formatBlockQuote(piece, depth, opts)
 		case parse.O_LIST:
-			pieceMdStr, patchSaveImageBytes = formatList(piece, depth)
+			pieceMdStr, patchSaveImageBytes = formatList(piece, depth, opts)
 		case parse.U_LIST:
-			pieceMdStr, patchSaveImageBytes = formatList(piece, depth)
+			pieceMdStr, patchSaveImageBytes = formatList(piece, depth, opts)
+		case parse.VIDEO:
+			pieceMdStr = formatVideo(piece, opts)
+		case parse.AUDIO:
+			pieceMdStr = formatAudio(piece, opts)
+		case parse.EMBED_CARD:
+			pieceMdStr = formatEmbedCard(piece, opts)
 		case parse.HR:
 			// TODO
 		case parse.BR:
-			pieceMdStr = "  \n"
+			pieceMdStr = p.lineBreak
 		case parse.NULL:
 			continue
 		}
 		contentMdStr += pieceMdStr
-		util.MergeMap(saveImageBytes, patchSaveImageBytes)
+		mergeMap(saveImageBytes, patchSaveImageBytes)
 	}
 	for i := 0; i < len(base64Imgs); i++ {
-		contentMdStr += "\n[" + strconv.Itoa(i) + "]:" + "data:image/png;base64," + base64Imgs[i]
+		contentMdStr += "\n[" + strconv.Itoa(i) + "]:" + imageDataURI(base64Imgs[i])
 	}
 	return contentMdStr, saveImageBytes
 }
 
-func formatTable(piece parse.Piece) string {
-	var tableMdStr string
-	if piece.Attrs != nil && piece.Attrs["type"] == "native" {
-		tableMdStr = piece.Val.(string)
-	}
-	// TODO
-	return tableMdStr
+// 各类型行内文字默认的修饰标记，piece 没有 marks 属性时使用
+var defaultMarks = map[parse.PieceType][]string{
+	parse.BOLD_TEXT:          {parse.MARK_BOLD},
+	parse.ITALIC_TEXT:        {parse.MARK_ITALIC},
+	parse.BOLD_ITALIC_TEXT:   {parse.MARK_BOLD, parse.MARK_ITALIC},
+	parse.STRIKETHROUGH_TEXT: {parse.MARK_STRIKE},
+	parse.UNDERLINE_TEXT:     {parse.MARK_UNDERLINE},
+	parse.HIGHLIGHT_TEXT:     {parse.MARK_HIGHLIGHT},
+	parse.SUP_TEXT:           {parse.MARK_SUP},
+	parse.SUB_TEXT:           {parse.MARK_SUB},
+	parse.CODE_INLINE:        {parse.MARK_CODE},
 }
 
-func formatBlockQuote(piece parse.Piece, depth int) (string, map[string][]byte) {
-	var bqMdString string
-	var prefix string = ">"
-	for i := 0; i < depth; i++ {
-		prefix += ">"
+// formatInlineText 按修饰标记由内到外包裹文字，首尾空白放在标记外面，否则markdown不认
+func formatInlineText(piece parse.Piece, opts *Options) string {
+	p := opts.profile()
+	text := piece.Val.(string)
+	marks := defaultMarks[piece.Type]
+	if piece.Attrs["marks"] != "" {
+		marks = strings.Split(piece.Attrs["marks"], ",")
 	}
-	prefix += " "
-	var saveImageBytes map[string][]byte
-	bqMdString, saveImageBytes = formatContent(piece.Val.([]parse.Piece), depth+1)
-	return prefix + bqMdString + "  \n", saveImageBytes
+	has := make(map[string]bool)
+	for _, m := range marks {
+		has[m] = true
+	}
+	core := strings.TrimSpace(text)
+	if core == "" {
+		return text
+	}
+	lead := text[:strings.Index(text, core)]
+	trail := text[len(lead)+len(core):]
+
+	if has[parse.MARK_CODE] {
+		core = formatCodeSpan(core)
+	}
+	if has[parse.MARK_SUP] {
+		core = "<sup>" + core + "</sup>"
+	}
+	if has[parse.MARK_SUB] {
+		core = "<sub>" + core + "</sub>"
+	}
+	if has[parse.MARK_UNDERLINE] {
+		core = "<u>" + core + "</u>"
+	}
+	if has[parse.MARK_STRIKE] {
+		core = p.strike[0] + core + p.strike[1]
+	}
+	if has[parse.MARK_HIGHLIGHT] {
+		core = p.highlight[0] + core + p.highlight[1]
+	}
+	switch {
+	case has[parse.MARK_BOLD] && has[parse.MARK_ITALIC]:
+		core = "***" + core + "***"
+	case has[parse.MARK_BOLD]:
+		core = "**" + core + "**"
+	case has[parse.MARK_ITALIC]:
+		core = "*" + core + "*"
+	}
+	return lead + core + trail
 }
 
-func formatList(li parse.Piece, depth int) (string, map[string][]byte) {
-	var listMdString string
-	var prefix string
-	for j := 0; j < depth; j++ {
-		prefix += "    "
+// formatCodeSpan 内容里有反引号时，用比其中最长的连续反引号多一个的反引号包裹
+func formatCodeSpan(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
 	}
+	fence := strings.Repeat("`", longest+1)
+	if longest > 0 {
+		return fence + " " + code + " " + fence
+	}
+	return fence + code + fence
+}
+
+// formatBlockQuote 引用内的每一行都加上 "> "，嵌套的引用、列表随之逐层加前缀
+func formatBlockQuote(piece parse.Piece, depth int, opts *Options) (string, map[string][]byte) {
+	bqMdString, saveImageBytes := formatContent(piece.Val.([]parse.Piece), depth+1, opts)
+	p := opts.profile()
+	return p.formatQuote(bqMdString, p.quoteCallout), saveImageBytes
+}
+
+// formatList 输出一个列表项，内容的后续行（含嵌套的子列表）按标记的宽度缩进
+func formatList(li parse.Piece, depth int, opts *Options) (string, map[string][]byte) {
+	var marker string
 	if li.Type == parse.U_LIST {
-		prefix += "- "
+		marker = "- "
 	} else if li.Type == parse.O_LIST {
-		prefix += strconv.Itoa(1) + ". " // 写死成1也大丈夫，markdown会自动累加序号
+		number := li.Attrs["number"]
+		if number == "" {
+			number = "1"
+		}
+		marker = number + ". "
 	}
-	var saveImageBytes map[string][]byte
-	listMdString, saveImageBytes = formatContent(li.Val.([]parse.Piece), depth+1)
-	return prefix + listMdString + "  \n", saveImageBytes
+	indent := strings.Repeat(" ", len(marker))
+	listMdString, saveImageBytes := formatContent(li.Val.([]parse.Piece), depth+1, opts)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(listMdString), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(lines) == 0 {
+			lines = append(lines, marker+line)
+		} else {
+			lines = append(lines, indent+line)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, strings.TrimSpace(marker))
+	}
+	return strings.Join(lines, "\n") + "\n", saveImageBytes
+}
+
+// startNewLine 标题、代码围栏必须顶格写在新的一行，去掉前面用于缩进的空白，必要时补上换行
+func startNewLine(mdStr string) string {
+	mdStr = strings.TrimRight(mdStr, " \t")
+	if mdStr != "" && !strings.HasSuffix(mdStr, "\n") {
+		mdStr += "\n"
+	}
+	return mdStr
+}
+
+// isList 是否为列表项
+func isList(piece parse.Piece) bool {
+	return piece.Type == parse.O_LIST || piece.Type == parse.U_LIST
 }
 
 func formatCodeBlock(piece parse.Piece) string {
 	var codeMdStr string
-	codeMdStr += "```\n"
 	codeRows := piece.Val.([]string)
+	fence := codeFence(codeRows)
+	codeMdStr += fence + piece.Attrs["lang"] + "\n"
 	for _, row := range codeRows {
 		codeMdStr += row + "\n"
 	}
-	codeMdStr += "```  \n"
+	codeMdStr += fence
 	return codeMdStr
 }
 
-// 图片地址为本身src
-func formatImageInline(piece parse.Piece) string {
-	return "![" + piece.Attrs["alt"] + "](" + piece.Attrs["src"] + " \"" + piece.Attrs["title"] + "\")  \n"
+// formatMath 行内公式写成 $...$，独占一行的公式写成前后各占一行的 $$
+func formatMath(piece parse.Piece) string {
+	if isDisplayMath(piece) {
+		return "$$\n" + mathTeX(piece) + "\n$$"
+	}
+	return "$" + mathTeX(piece) + "$"
+}
+
+// codeFence 代码里有 ``` 时，用更长的反引号作为围栏
+func codeFence(codeRows []string) string {
+	fence := "```"
+	for _, row := range codeRows {
+		for strings.Contains(row, fence) {
+			fence += "`"
+		}
+	}
+	return fence
+}
+
+// imageFileName 要保存到本地的图片的文件名，扩展名取自图片内容，判断不出时取 src 中的 wx_fmt
+func imageFileName(piece parse.Piece) string {
+	imgExt := parse.ImageTypeExt(imageType(piece))
+	if imgExt == "" {
+		imgExt = parseImageExtFromSrc(piece.Attrs["src"])
+	}
+	if imgExt == "" {
+		// 如果无法确定扩展名，使用默认值
+		imgExt = "png"
+	}
+	return md5Hex(piece.Val.([]byte)) + "." + imgExt
+}
+
+// imageType 图片的 MIME 类型：优先用解析时记录的类型，没有时按内容判断，都判断不出时返回空字符串
+func imageType(piece parse.Piece) string {
+	if mimeType := piece.Attrs[parse.IMAGE_ATTR_TYPE]; mimeType != "" {
+		return mimeType
+	}
+	switch data := piece.Val.(type) {
+	case []byte:
+		return parse.DetectImageType(data)
+	case string:
+		// base64 只解码开头的一段就够判断类型
+		head := data
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(head[:len(head)/4*4]); err == nil {
+			return parse.DetectImageType(decoded)
+		}
+	}
+	return ""
+}
+
+// imageDataURI base64 图片的 data URI
+func imageDataURI(piece parse.Piece) string {
+	return "data:" + firstNonEmpty(imageType(piece), "image/png") + ";base64," + piece.Val.(string)
 }
 
-// 图片地址为本地引用
-func formatImageFileReferInline(alt string, refName string) string {
-	return "![" + alt + "](" + refName + ")  \n"
+// 图片地址为本身src
+func formatImageInline(piece parse.Piece) string {
+	return "![" + piece.Attrs["alt"] + "](" + piece.Attrs["src"] + " \"" + piece.Attrs["title"] + "\")"
 }
 
 // 图片转成base64并插在原地
 func formatImageBase64Inline(piece parse.Piece) string {
-	return "![" + piece.Attrs["alt"] + "](data:image/png;base64," + piece.Val.(string) + ")  \n"
+	return "![" + piece.Attrs["alt"] + "](" + imageDataURI(piece) + ")"
 }
 
 // 图片地址为markdown内引用（用于base64）
 func formatImageRefer(piece parse.Piece, index int) string {
-	return "![" + piece.Attrs["alt"] + "][" + strconv.Itoa(index) + "]  \n"
+	return "![" + piece.Attrs["alt"] + "][" + strconv.Itoa(index) + "]"
 }
 
 func formatLink(piece parse.Piece) string {
-	var linkMdStr string = "[" + piece.Val.(string) + "](" + piece.Attrs["href"] + ")  \n"
+	var linkMdStr string = "[" + piece.Val.(string) + "](" + piece.Attrs["href"] + ")"
 	return linkMdStr
 }
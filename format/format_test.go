@@ -0,0 +1,74 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestFormatList(t *testing.T) {
+	u := func(content ...parse.Piece) parse.Piece { return listItem(parse.U_LIST, "", content...) }
+	o := func(number string, content ...parse.Piece) parse.Piece {
+		return listItem(parse.O_LIST, number, content...)
+	}
+	tests := []struct {
+		name   string
+		pieces []parse.Piece
+		want   string
+	}{
+		{"unordered", []parse.Piece{u(text("a")), u(text("b"))}, "\n- a\n- b\n"},
+		{"ordered from 3", []parse.Piece{o("3", text("a")), o("4", text("b"))}, "\n3. a\n4. b\n"},
+		{"nested", []parse.Piece{u(text("a"), u(text("a1")), o("1", text("x"))), u(text("b"))}, "\n- a\n  - a1\n  1. x\n- b\n"},
+		{"nested under ordered", []parse.Piece{o("10", text("a"), u(text("a1")))}, "\n10. a\n    - a1\n"},
+		{"text after list", []parse.Piece{u(text("a")), text("后文")}, "\n- a\n\n后文"},
+	}
+	for _, tt := range tests {
+		got, _ := formatContent(tt.pieces, 0, &Options{})
+		if got != tt.want {
+			t.Errorf("%s:\n got: %q\nwant: %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCodeBlock(t *testing.T) {
+	tests := []struct {
+		rows []string
+		lang string
+		want string
+	}{
+		{[]string{"a := 1", "", "b := 2"}, "go", "```go\na := 1\n\nb := 2\n```"},
+		{[]string{"x"}, "", "```\nx\n```"},
+		{[]string{"```js", "code", "```"}, "markdown", "````markdown\n```js\ncode\n```\n````"},
+	}
+	for _, tt := range tests {
+		piece := parse.Piece{Type: parse.CODE_BLOCK, Val: tt.rows, Attrs: map[string]string{"lang": tt.lang}}
+		if got := formatCodeBlock(piece); got != tt.want {
+			t.Errorf("formatCodeBlock(%q):\n got: %q\nwant: %q", tt.rows, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAndSaveErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	article := parse.Article{
+		Title:   parse.Piece{Type: parse.HEADER, Val: "标题", Attrs: map[string]string{"level": "1"}},
+		Content: []parse.Piece{{Type: parse.NORMAL_TEXT, Val: "正文"}},
+	}
+	// 目录建不出来时返回错误而不是退出
+	if err := FormatAndSave(article, filepath.Join(file, "sub", "a.md")); err == nil {
+		t.Error("save under a regular file: err = nil")
+	}
+	// 没有标题的文章也能保存
+	if err := FormatAndSave(parse.Article{}, filepath.Join(dir, "empty.md")); err != nil {
+		t.Errorf("save empty article: %v", err)
+	}
+	if md, _ := Format(parse.Article{Content: article.Content}); md != "正文" {
+		t.Errorf("Format without title = %q, want only the content", md)
+	}
+}
@@ -0,0 +1,268 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+type FrontMatterStyle int32
+
+const (
+	FRONT_MATTER_NONE       FrontMatterStyle = iota // 不输出 front matter
+	FRONT_MATTER_YAML                               // --- 包裹的 YAML（Hugo、Hexo、Obsidian 等）
+	FRONT_MATTER_TOML                               // +++ 包裹的 TOML（Hugo）
+	FRONT_MATTER_PROPERTIES                         // 文件开头的 key:: value 属性（Logseq）
+)
+
+func FrontMatterArgValue2FrontMatterStyle(val string) FrontMatterStyle {
+	switch val {
+	case "yaml", "yml":
+		return FRONT_MATTER_YAML
+	case "toml":
+		return FRONT_MATTER_TOML
+	case "properties":
+		return FRONT_MATTER_PROPERTIES
+	default:
+		return FRONT_MATTER_NONE
+	}
+}
+
+// FrontMatterField front matter 中的一个字段，Key 为取值的字段，Name 为输出时的名字
+type FrontMatterField struct {
+	Key  string
+	Name string
+}
+
+// front matter 可用的字段
+const (
+	FM_TITLE          = "title"          // 标题
+	FM_AUTHOR         = "author"         // 作者
+	FM_ACCOUNT        = "account"        // 公众号名称
+	FM_DATE           = "date"           // 发布时间
+	FM_TAGS           = "tags"           // 标签
+	FM_COVER          = "cover"          // 封面图
+	FM_URL            = "url"            // 文章链接
+	FM_SOURCE         = "source"         // “阅读原文”链接
+	FM_DIGEST         = "digest"         // 摘要
+	FM_ORIGIN         = "origin"         // 转载、分享的原文链接
+	FM_ORIGIN_ACCOUNT = "origin_account" // 转载、分享的原文公众号
+)
+
+// DefaultFrontMatterFields 未指定字段时输出的字段
+var DefaultFrontMatterFields = []FrontMatterField{
+	{FM_TITLE, FM_TITLE},
+	{FM_AUTHOR, FM_AUTHOR},
+	{FM_ACCOUNT, FM_ACCOUNT},
+	{FM_DATE, FM_DATE},
+	{FM_TAGS, FM_TAGS},
+	{FM_COVER, FM_COVER},
+	{FM_URL, FM_URL},
+	{FM_SOURCE, FM_SOURCE},
+	{FM_DIGEST, FM_DIGEST},
+	{FM_ORIGIN, FM_ORIGIN},
+	{FM_ORIGIN_ACCOUNT, FM_ORIGIN_ACCOUNT},
+}
+
+// ParseFrontMatterFields 解析形如 "title,date:publishDate,tags" 的字段列表，冒号后为输出时的名字
+func ParseFrontMatterFields(val string) []FrontMatterField {
+	var fields []FrontMatterField
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, name := item, item
+		if i := strings.Index(item, ":"); i >= 0 {
+			key, name = strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+1:])
+		}
+		fields = append(fields, FrontMatterField{key, name})
+	}
+	return fields
+}
+
+// formatFrontMatter 值为空的字段不输出
+func formatFrontMatter(article parse.Article, opts *Options) string {
+	if opts.FrontMatter == FRONT_MATTER_NONE {
+		return ""
+	}
+	p := opts.profile()
+	fields := opts.FrontMatterFields
+	if len(fields) == 0 {
+		fields = p.frontMatterFields
+	}
+	if len(fields) == 0 {
+		fields = DefaultFrontMatterFields
+	}
+	dateLayout := p.dateLayout
+	if dateLayout == "" {
+		dateLayout = time.RFC3339
+	}
+	var lines []string
+	for _, field := range fields {
+		val := frontMatterValue(article, field.Key)
+		if val == nil {
+			continue
+		}
+		switch opts.FrontMatter {
+		case FRONT_MATTER_TOML:
+			lines = append(lines, tomlKey(field.Name)+" = "+tomlValue(val))
+		case FRONT_MATTER_PROPERTIES:
+			lines = append(lines, field.Name+":: "+propertyValue(val, dateLayout))
+		default:
+			lines = append(lines, yamlKey(field.Name)+":"+yamlValue(val, dateLayout))
+		}
+	}
+	if opts.FrontMatter == FRONT_MATTER_PROPERTIES {
+		return strings.Join(lines, "\n") + "\n\n"
+	}
+	delimiter := "---"
+	if opts.FrontMatter == FRONT_MATTER_TOML {
+		delimiter = "+++"
+	}
+	return delimiter + "\n" + strings.Join(lines, "\n") + "\n" + delimiter + "\n\n"
+}
+
+// frontMatterValue 返回 string、[]string 或 time.Time，没有值时返回 nil
+func frontMatterValue(article parse.Article, key string) any {
+	md := article.Metadata
+	var val string
+	switch key {
+	case FM_TITLE:
+		val, _ = article.Title.Val.(string)
+		val = strings.TrimSpace(val)
+	case FM_AUTHOR:
+		val = md.Author
+	case FM_ACCOUNT:
+		val = md.AccountName
+	case FM_DATE:
+		if md.PublishTime.IsZero() {
+			return nil
+		}
+		return md.PublishTime
+	case FM_TAGS:
+		if tags := splitTags(article.Tags); len(tags) > 0 {
+			return tags
+		}
+		return nil
+	case FM_COVER:
+		val = md.Cover
+	case FM_URL:
+		val = md.URL
+	case FM_SOURCE:
+		val = md.SourceURL
+	case FM_DIGEST:
+		val = md.Digest
+	case FM_ORIGIN:
+		val = md.ShareURL
+	case FM_ORIGIN_ACCOUNT:
+		val = md.ShareAccount
+	}
+	if val == "" {
+		return nil
+	}
+	return val
+}
+
+// splitTags 页面上的标签形如 "#标签1 #标签2"
+func splitTags(tags string) []string {
+	var res []string
+	for _, tag := range strings.FieldsFunc(tags, func(r rune) bool {
+		return r == '#' || r == ' ' || r == '\n' || r == '\t'
+	}) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
+var bareKeyReg = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func yamlKey(key string) string {
+	if bareKeyReg.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+func yamlValue(val any, dateLayout string) string {
+	switch v := val.(type) {
+	case time.Time:
+		return " " + v.Format(dateLayout)
+	case []string:
+		var items string
+		for _, item := range v {
+			items += "\n  - " + quoteString(item)
+		}
+		return items
+	default:
+		return " " + quoteString(val.(string))
+	}
+}
+
+func tomlKey(key string) string {
+	if bareKeyReg.MatchString(key) {
+		return key
+	}
+	return quoteString(key)
+}
+
+// tomlValue 日期不加引号就是 TOML 的日期时间类型，必须是 RFC 3339 格式，所以不用方言的日期格式
+func tomlValue(val any) string {
+	switch v := val.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []string:
+		var items []string
+		for _, item := range v {
+			items = append(items, quoteString(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return quoteString(val.(string))
+	}
+}
+
+// propertyValue 属性值不加引号，多个值以逗号分隔，不能换行
+func propertyValue(val any, dateLayout string) string {
+	switch v := val.(type) {
+	case time.Time:
+		return v.Format(dateLayout)
+	case []string:
+		return strings.Join(v, ", ")
+	default:
+		return blankReg.ReplaceAllString(val.(string), " ")
+	}
+}
+
+// quoteString 双引号字符串，YAML 和 TOML 的转义规则在这里是通用的：
+// TOML 的基本字符串里不能直接出现控制字符，其余的控制字符写成 \uXXXX
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString("\\\\")
+		case r == '"':
+			b.WriteString("\\\"")
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\r':
+			b.WriteString("\\r")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\u%04X", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func frontMatterArticle() parse.Article {
+	return parse.Article{
+		Title: parse.Piece{Type: parse.HEADER, Val: "标题 \"引号\"", Attrs: map[string]string{"level": "1"}},
+		Tags:  "#标签1 #标签2",
+		Metadata: parse.Metadata{
+			AccountName: "公众号",
+			PublishTime: time.Date(2024, 10, 7, 8, 30, 0, 0, time.FixedZone("CST", 8*3600)),
+			Digest:      "第一行\n第二行\x01",
+		},
+	}
+}
+
+func TestFormatFrontMatter(t *testing.T) {
+	fields := ParseFrontMatterFields("title,date:publishDate,tags,account,digest")
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "yaml",
+			opts: Options{FrontMatter: FRONT_MATTER_YAML, FrontMatterFields: fields},
+			want: "---\ntitle: \"标题 \\\"引号\\\"\"\npublishDate: 2024-10-07T08:30:00+08:00\ntags:\n  - \"标签1\"\n  - \"标签2\"\n" +
+				"account: \"公众号\"\ndigest: \"第一行\\n第二行\\u0001\"\n---\n\n",
+		},
+		{
+			name: "yaml uses the flavor date layout",
+			opts: Options{FrontMatter: FRONT_MATTER_YAML, FrontMatterFields: ParseFrontMatterFields("date"), Flavor: FLAVOR_HEXO},
+			want: "---\ndate: 2024-10-07 08:30:00\n---\n\n",
+		},
+		{
+			name: "toml",
+			opts: Options{FrontMatter: FRONT_MATTER_TOML, FrontMatterFields: fields},
+			want: "+++\ntitle = \"标题 \\\"引号\\\"\"\npublishDate = 2024-10-07T08:30:00+08:00\ntags = [\"标签1\", \"标签2\"]\n" +
+				"account = \"公众号\"\ndigest = \"第一行\\n第二行\\u0001\"\n+++\n\n",
+		},
+		{
+			name: "toml dates stay RFC 3339 whatever the flavor",
+			opts: Options{FrontMatter: FRONT_MATTER_TOML, FrontMatterFields: ParseFrontMatterFields("date"), Flavor: FLAVOR_HEXO},
+			want: "+++\ndate = 2024-10-07T08:30:00+08:00\n+++\n\n",
+		},
+		{
+			name: "properties",
+			opts: Options{FrontMatter: FRONT_MATTER_PROPERTIES, FrontMatterFields: ParseFrontMatterFields("title:Title,tags,digest")},
+			want: "Title:: 标题 \"引号\"\ntags:: 标签1, 标签2\ndigest:: 第一行 第二行\x01\n\n",
+		},
+		{
+			name: "none",
+			opts: Options{FrontMatterFields: fields},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatFrontMatter(frontMatterArticle(), &tt.opts); got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	tests := map[string]string{
+		"plain":          `"plain"`,
+		`a\b"c`:          `"a\\b\"c"`,
+		"tab\tcr\r":      `"tab\tcr\r"`,
+		"nul\x00del\x7f": `"nul\u0000del\u007F"`,
+		"esc\x1b":        `"esc\u001B"`,
+	}
+	for in, want := range tests {
+		if got := quoteString(in); got != want {
+			t.Errorf("quoteString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package format
+
+import (
+	"archive/zip"
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+var update = flag.Bool("update", false, "用当前的输出更新 testdata 中的 golden 文件")
+
+// checkGolden 与 testdata/<name> 比较输出，带 -update 运行时改为写入
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v (run go test -update to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("output differs from %s:\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func text(s string) parse.Piece {
+	return parse.Piece{Type: parse.NORMAL_TEXT, Val: s}
+}
+
+func header(level string, s string) parse.Piece {
+	return parse.Piece{Type: parse.HEADER, Val: s, Attrs: map[string]string{"level": level}}
+}
+
+func listItem(typ parse.PieceType, number string, content ...parse.Piece) parse.Piece {
+	attrs := map[string]string{}
+	if number != "" {
+		attrs["number"] = number
+	}
+	return parse.Piece{Type: typ, Val: content, Attrs: attrs}
+}
+
+func cell(s string) parse.TableCell {
+	return parse.TableCell{Content: []parse.Piece{text(s)}, ColSpan: 1, RowSpan: 1}
+}
+
+// headerCell 表头单元格，与解析器对表头行的标记一致
+func headerCell(s string) parse.TableCell {
+	c := cell(s)
+	c.IsHeader = true
+	return c
+}
+
+// sampleArticle 覆盖各渲染器主要元素的文章
+func sampleArticle() parse.Article {
+	br := parse.Piece{Type: parse.BR}
+	return parse.Article{
+		Title: header("1", "示例文章"),
+		Metadata: parse.Metadata{
+			AccountName: "示例公众号",
+			Author:      "作者",
+			URL:         "https://mp.weixin.qq.com/s/sample",
+			PublishTime: time.Date(2024, 5, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600)),
+			SourceURL:   "https://example.com/source",
+		},
+		Tags: "#标签1 #标签2",
+		Content: []parse.Piece{
+			text("第一段，"),
+			{Type: parse.BOLD_TEXT, Val: "粗体"},
+			text("和"),
+			{Type: parse.STRIKETHROUGH_TEXT, Val: "删除线"},
+			text("。"),
+			br,
+			header("2", "一 列表"),
+			listItem(parse.U_LIST, "", text("苹果"), listItem(parse.U_LIST, "", text("红富士"))),
+			listItem(parse.U_LIST, "", text("香蕉")),
+			listItem(parse.O_LIST, "1", text("第一步")),
+			listItem(parse.O_LIST, "2", text("第二步")),
+			header("3", "1 代码"),
+			{Type: parse.CODE_BLOCK, Val: []string{"func main() {", "", "\tprintln(\"hi\")", "}"}, Attrs: map[string]string{"lang": "go"}},
+			header("2", "二 引用和表格"),
+			{Type: parse.BLOCK_QUOTES, Val: []parse.Piece{text("引用的第一行"), br, text("引用的第二行")}},
+			{Type: parse.TABLE, Val: parse.Table{HasHeader: true, Rows: []parse.TableRow{
+				{headerCell("名称"), headerCell("数量")},
+				{cell("苹果"), cell("3")},
+			}}},
+			{Type: parse.LINK, Val: "链接", Attrs: map[string]string{"href": "https://example.com/"}},
+			{Type: parse.IMAGE, Attrs: map[string]string{"src": "https://mmbiz.qpic.cn/sample.png", "alt": "图片"}},
+		},
+	}
+}
+
+// zipDump 按顺序列出 zip 中的文件和内容，用于 golden 比较；图片等二进制文件只写大小
+func zipDump(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	var buf bytes.Buffer
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		method := "deflate"
+		if f.Method == zip.Store {
+			method = "store"
+		}
+		if utf8.Valid(content) && !bytes.ContainsRune(content, 0) {
+			fmt.Fprintf(&buf, "=== %s (%s)\n%s\n", f.Name, method, content)
+		} else {
+			fmt.Fprintf(&buf, "=== %s (%s) %d bytes\n", f.Name, method, len(content))
+		}
+	}
+	return buf.Bytes()
+}
+
+// unzipFiles 取出 zip 中的全部文件
+func unzipFiles(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+// pngData 4x3 的 PNG 图片
+func pngData(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
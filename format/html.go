@@ -0,0 +1,398 @@
+package format
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// htmlRenderer 输出可以离线打开的独立html文件：语义化的标签加一段内嵌样式，不带公众号的内联样式和脚本。
+// base64 图片以 data URI 内嵌在文件里，保存到本地的图片放在html文件旁边
+type htmlRenderer struct{}
+
+func (htmlRenderer) Render(article parse.Article, opts Options) ([]byte, map[string][]byte, error) {
+	title, _ := article.Title.Val.(string)
+	title = html.EscapeString(strings.TrimSpace(title))
+	content, saveImageBytes := formatHTMLBlocks(article.Content)
+	md := article.Metadata
+
+	var doc strings.Builder
+	doc.WriteString("<!DOCTYPE html>\n<html lang=\"zh-CN\">\n<head>\n<meta charset=\"utf-8\">\n")
+	doc.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	doc.WriteString("<title>" + title + "</title>\n<style>\n" + htmlCSS + "</style>\n</head>\n<body>\n<article>\n")
+	doc.WriteString("<header>\n<h1>" + title + "</h1>\n")
+	var meta []string
+	for _, val := range []string{md.Author, md.AccountName} {
+		if val != "" && !containsString(meta, html.EscapeString(val)) {
+			meta = append(meta, html.EscapeString(val))
+		}
+	}
+	if !md.PublishTime.IsZero() {
+		meta = append(meta, "<time datetime=\""+md.PublishTime.Format(time.RFC3339)+"\">"+md.PublishTime.Format("2006-01-02 15:04")+"</time>")
+	}
+	if len(meta) > 0 {
+		doc.WriteString("<p class=\"meta\">" + strings.Join(meta, " · ") + "</p>\n")
+	}
+	doc.WriteString("</header>\n")
+	doc.WriteString(content)
+	if tags := splitTags(article.Tags); len(tags) > 0 || md.URL != "" || md.SourceURL != "" || md.ShareURL != "" || md.ShareAccount != "" {
+		doc.WriteString("<footer>\n")
+		if len(tags) > 0 {
+			doc.WriteString("<p class=\"tags\">#" + html.EscapeString(strings.Join(tags, " #")) + "</p>\n")
+		}
+		if md.URL != "" {
+			doc.WriteString("<p>原文链接：<a href=\"" + html.EscapeString(md.URL) + "\">" + html.EscapeString(md.URL) + "</a></p>\n")
+		}
+		if md.ShareURL != "" || md.ShareAccount != "" {
+			doc.WriteString("<p>转载自：" + formatHTMLSourceLink(firstNonEmpty(md.ShareAccount, md.ShareURL), md.ShareURL) + "</p>\n")
+		}
+		if md.SourceURL != "" {
+			doc.WriteString("<p><a href=\"" + html.EscapeString(md.SourceURL) + "\">阅读原文</a></p>\n")
+		}
+		doc.WriteString("</footer>\n")
+	}
+	doc.WriteString("</article>\n</body>\n</html>\n")
+	return []byte(doc.String()), saveImageBytes, nil
+}
+
+// formatHTMLSourceLink 来源链接，没有地址时只输出文字
+func formatHTMLSourceLink(text string, url string) string {
+	if url == "" {
+		return html.EscapeString(text)
+	}
+	return "<a href=\"" + html.EscapeString(url) + "\">" + html.EscapeString(text) + "</a>"
+}
+
+func (htmlRenderer) Ext() string {
+	return "html"
+}
+
+// htmlCSS 内嵌在独立html文件中的样式
+const htmlCSS = `body { margin: 0; background: #fff; color: #222; font: 17px/1.75 -apple-system, "PingFang SC", "Microsoft YaHei", sans-serif; }
+article { max-width: 680px; margin: 0 auto; padding: 24px 16px 48px; }
+h1 { font-size: 1.6em; line-height: 1.4; margin: 0 0 8px; }
+h2, h3, h4, h5, h6 { line-height: 1.4; margin: 1.6em 0 0.6em; }
+p { margin: 0.8em 0; }
+a { color: #576b95; text-decoration: none; }
+img, video, iframe { max-width: 100%; height: auto; }
+figure { margin: 1em 0; text-align: center; }
+figcaption { color: #888; font-size: 0.85em; }
+.math.display { margin: 1em 0; overflow-x: auto; text-align: center; }
+blockquote { margin: 1em 0; padding: 0.2em 1em; border-left: 4px solid #ddd; color: #666; }
+pre { overflow-x: auto; padding: 12px; background: #f6f8fa; border-radius: 4px; font-size: 0.85em; line-height: 1.5; }
+code { font-family: Menlo, Consolas, monospace; }
+:not(pre) > code { padding: 0.1em 0.3em; background: #f6f8fa; border-radius: 3px; font-size: 0.9em; }
+table { border-collapse: collapse; margin: 1em 0; display: block; overflow-x: auto; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; }
+th { background: #f6f8fa; }
+hr { border: none; border-top: 1px solid #eee; margin: 2em 0; }
+aside.card { margin: 1em 0; padding: 10px 14px; border: 1px solid #eee; border-radius: 6px; }
+aside.card img { max-width: 64px; float: right; margin-left: 10px; }
+.meta, footer { color: #888; font-size: 0.9em; }
+footer { margin-top: 3em; padding-top: 1em; border-top: 1px solid #eee; }
+`
+
+// formatHTMLBlocks 把 pieces 输出成语义化的html：行内内容以换行为界分成段落，独占一段的图片输出为 figure
+func formatHTMLBlocks(pieces []parse.Piece) (string, map[string][]byte) {
+	var htmlStr string
+	saveImageBytes := make(map[string][]byte)
+	var inline []parse.Piece
+	flush := func() {
+		paragraphHTML, images := formatHTMLParagraph(inline)
+		mergeMap(saveImageBytes, images)
+		htmlStr += paragraphHTML
+		inline = nil
+	}
+	for i, piece := range pieces {
+		if isHTMLInline(piece) {
+			inline = append(inline, piece)
+			continue
+		}
+		flush()
+		var blockHTML string
+		var images map[string][]byte
+		switch piece.Type {
+		case parse.BR, parse.NULL:
+			continue
+		case parse.BLOCK_QUOTES:
+			blockHTML, images = formatHTMLBlocks(piece.Val.([]parse.Piece))
+			blockHTML = "<blockquote>\n" + blockHTML + "</blockquote>\n"
+		case parse.O_LIST, parse.U_LIST:
+			tag := "ul"
+			if piece.Type == parse.O_LIST {
+				tag = "ol"
+			}
+			// 相邻的同类列表项放进同一个列表里
+			if i == 0 || pieces[i-1].Type != piece.Type {
+				if number := piece.Attrs["number"]; number != "" && number != "1" {
+					blockHTML += "<" + tag + " start=\"" + number + "\">\n"
+				} else {
+					blockHTML += "<" + tag + ">\n"
+				}
+			}
+			itemHTML, itemImages := formatHTMLListItem(piece.Val.([]parse.Piece))
+			images = itemImages
+			blockHTML += "<li>" + itemHTML + "</li>\n"
+			if i == len(pieces)-1 || pieces[i+1].Type != piece.Type {
+				blockHTML += "</" + tag + ">\n"
+			}
+		case parse.VIDEO:
+			blockHTML = formatVideoHTML(piece)
+			if blockHTML == "" {
+				src := firstNonEmpty(piece.Attrs["src"], piece.Attrs["poster"])
+				blockHTML = "<a href=\"" + html.EscapeString(src) + "\">"
+				if poster := piece.Attrs["poster"]; poster != "" {
+					blockHTML += "<img src=\"" + html.EscapeString(poster) + "\" alt=\"\"/>"
+				}
+				blockHTML += "</a>"
+			}
+			blockHTML = "<figure>" + blockHTML + "<figcaption>" + html.EscapeString(mediaTitle(piece, "视频")) + "</figcaption></figure>\n"
+		case parse.AUDIO:
+			blockHTML = "<figure>" + formatAudioHTML(piece) + "<figcaption>" + html.EscapeString(mediaTitle(piece, "音频")) + "</figcaption></figure>\n"
+		case parse.EMBED_CARD:
+			blockHTML = formatHTMLCard(piece)
+		default:
+			blockHTML, images = formatHTMLPieces([]parse.Piece{piece})
+			blockHTML = strings.TrimSuffix(blockHTML, "\n") + "\n"
+		}
+		htmlStr += blockHTML
+		mergeMap(saveImageBytes, images)
+	}
+	flush()
+	return htmlStr, saveImageBytes
+}
+
+// isHTMLInline 是否为段落中的行内内容
+func isHTMLInline(piece parse.Piece) bool {
+	switch piece.Type {
+	case parse.NORMAL_TEXT, parse.BOLD_TEXT, parse.ITALIC_TEXT, parse.BOLD_ITALIC_TEXT,
+		parse.STRIKETHROUGH_TEXT, parse.UNDERLINE_TEXT, parse.HIGHLIGHT_TEXT, parse.SUP_TEXT, parse.SUB_TEXT,
+		parse.CODE_INLINE, parse.LINK, parse.IMAGE, parse.IMAGE_BASE64:
+		return true
+	case parse.MATH:
+		return !isDisplayMath(piece)
+	}
+	return false
+}
+
+// formatHTMLParagraph 空白段落不输出；只有图片的段落每张图输出一个 figure
+func formatHTMLParagraph(pieces []parse.Piece) (string, map[string][]byte) {
+	onlyImages, blank := true, true
+	var images []parse.Piece
+	for _, piece := range pieces {
+		switch piece.Type {
+		case parse.IMAGE, parse.IMAGE_BASE64:
+			images = append(images, piece)
+			blank = false
+		default:
+			if text, _ := piece.Val.(string); !isBlankText(text) {
+				onlyImages, blank = false, false
+			}
+		}
+	}
+	if blank {
+		return "", nil
+	}
+	if onlyImages {
+		var htmlStr string
+		saveImageBytes := make(map[string][]byte)
+		for _, image := range images {
+			imageHTML, imageBytes := formatHTMLPieces([]parse.Piece{image})
+			mergeMap(saveImageBytes, imageBytes)
+			htmlStr += "<figure>" + imageHTML + "</figure>\n"
+		}
+		return htmlStr, saveImageBytes
+	}
+	htmlStr, saveImageBytes := formatHTMLPieces(pieces)
+	return "<p>" + strings.TrimSpace(htmlStr) + "</p>\n", saveImageBytes
+}
+
+// formatHTMLListItem 只有行内内容的列表项不再套段落
+func formatHTMLListItem(pieces []parse.Piece) (string, map[string][]byte) {
+	for _, piece := range pieces {
+		if !isHTMLInline(piece) && piece.Type != parse.BR && piece.Type != parse.NULL {
+			itemHTML, saveImageBytes := formatHTMLBlocks(pieces)
+			return "\n" + itemHTML, saveImageBytes
+		}
+	}
+	itemHTML, saveImageBytes := formatHTMLPieces(trimBr(pieces))
+	return strings.TrimSpace(itemHTML), saveImageBytes
+}
+
+// formatHTMLCard 小程序、公众号名片等卡片
+func formatHTMLCard(piece parse.Piece) string {
+	title := html.EscapeString(firstNonEmpty(piece.Attrs["title"], piece.Attrs["url"]))
+	if url := piece.Attrs["url"]; url != "" {
+		title = "<a href=\"" + html.EscapeString(url) + "\">" + title + "</a>"
+	}
+	cardHTML := "<aside class=\"card\">"
+	if image := piece.Attrs["image"]; image != "" {
+		cardHTML += "<img src=\"" + html.EscapeString(image) + "\" alt=\"\"/>"
+	}
+	cardHTML += "<strong>" + title + "</strong>"
+	if desc := piece.Attrs["desc"]; desc != "" {
+		cardHTML += "<br/>" + html.EscapeString(desc)
+	}
+	return cardHTML + "</aside>\n"
+}
+
+// trimBr 去掉首尾的换行
+func trimBr(pieces []parse.Piece) []parse.Piece {
+	for len(pieces) > 0 && pieces[0].Type == parse.BR {
+		pieces = pieces[1:]
+	}
+	for len(pieces) > 0 && pieces[len(pieces)-1].Type == parse.BR {
+		pieces = pieces[:len(pieces)-1]
+	}
+	return pieces
+}
+
+// isBlankText 只有空白和零宽字符的文字
+func isBlankText(text string) bool {
+	return strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '\u200b' || r == '\u200c' || r == '\u200d' || r == '\ufeff'
+	}) == ""
+}
+
+// formatHTMLPieces 把 pieces 输出成不带样式的html片段
+func formatHTMLPieces(pieces []parse.Piece) (string, map[string][]byte) {
+	var htmlStr string
+	saveImageBytes := make(map[string][]byte)
+	for i, piece := range pieces {
+		switch piece.Type {
+		case parse.HEADER:
+			level := piece.Attrs["level"]
+			if level == "" {
+				level = "2"
+			}
+			htmlStr += "<h" + level + htmlIDAttr(piece) + ">" + html.EscapeString(strings.TrimSpace(piece.Val.(string))) + "</h" + level + ">\n"
+		case parse.NORMAL_TEXT:
+			htmlStr += html.EscapeString(blankReg.ReplaceAllString(piece.Val.(string), " "))
+		case parse.BOLD_TEXT, parse.ITALIC_TEXT, parse.BOLD_ITALIC_TEXT,
+			parse.STRIKETHROUGH_TEXT, parse.UNDERLINE_TEXT, parse.HIGHLIGHT_TEXT, parse.SUP_TEXT, parse.SUB_TEXT,
+			parse.CODE_INLINE:
+			htmlStr += formatHTMLInlineText(piece)
+		case parse.LINK:
+			htmlStr += "<a href=\"" + html.EscapeString(piece.Attrs["href"]) + "\">" + html.EscapeString(piece.Val.(string)) + "</a>"
+		case parse.MATH:
+			htmlStr += formatHTMLMath(piece)
+		case parse.IMAGE:
+			src := piece.Attrs["src"]
+			if piece.Val != nil {
+				src = imageFileName(piece)
+				saveImageBytes[src] = piece.Val.([]byte)
+			}
+			htmlStr += "<img src=\"" + html.EscapeString(src) + "\" alt=\"" + html.EscapeString(piece.Attrs["alt"]) + "\"/>"
+		case parse.IMAGE_BASE64:
+			htmlStr += "<img src=\"" + imageDataURI(piece) + "\" alt=\"" + html.EscapeString(piece.Attrs["alt"]) + "\"/>"
+		case parse.TABLE:
+			tableHTML, images := formatHTMLTable(piece.Val.(parse.Table))
+			mergeMap(saveImageBytes, images)
+			htmlStr += tableHTML
+		case parse.CODE_BLOCK:
+			var langAttr string
+			if lang := piece.Attrs["lang"]; lang != "" {
+				langAttr = " class=\"language-" + html.EscapeString(lang) + "\""
+			}
+			htmlStr += "<pre><code" + langAttr + ">" + html.EscapeString(strings.Join(piece.Val.([]string), "\n")) + "</code></pre>"
+		case parse.BLOCK_QUOTES:
+			quoteHTML, images := formatHTMLPieces(piece.Val.([]parse.Piece))
+			mergeMap(saveImageBytes, images)
+			htmlStr += "<blockquote>" + quoteHTML + "</blockquote>"
+		case parse.O_LIST, parse.U_LIST:
+			tag := "ul"
+			if piece.Type == parse.O_LIST {
+				tag = "ol"
+			}
+			// 相邻的同类列表项放进同一个列表里
+			if i == 0 || pieces[i-1].Type != piece.Type {
+				if number := piece.Attrs["number"]; number != "" && number != "1" {
+					htmlStr += "<" + tag + " start=\"" + number + "\">"
+				} else {
+					htmlStr += "<" + tag + ">"
+				}
+			}
+			itemHTML, images := formatHTMLPieces(piece.Val.([]parse.Piece))
+			mergeMap(saveImageBytes, images)
+			htmlStr += "<li>" + itemHTML + "</li>"
+			if i == len(pieces)-1 || pieces[i+1].Type != piece.Type {
+				htmlStr += "</" + tag + ">"
+			}
+		case parse.VIDEO:
+			videoHTML := formatVideoHTML(piece)
+			if videoHTML == "" {
+				src := piece.Attrs["src"]
+				if src == "" {
+					src = piece.Attrs["poster"]
+				}
+				videoHTML = "<a href=\"" + html.EscapeString(src) + "\">" + html.EscapeString(mediaTitle(piece, "视频")) + "</a>"
+			}
+			htmlStr += videoHTML
+		case parse.AUDIO:
+			htmlStr += formatAudioHTML(piece)
+		case parse.EMBED_CARD:
+			cardHTML := html.EscapeString(piece.Attrs["title"])
+			if url := piece.Attrs["url"]; url != "" {
+				cardHTML = "<a href=\"" + html.EscapeString(url) + "\">" + cardHTML + "</a>"
+			}
+			htmlStr += cardHTML
+		case parse.HR:
+			htmlStr += "<hr/>"
+		case parse.BR:
+			htmlStr += "<br/>"
+		}
+	}
+	return htmlStr, saveImageBytes
+}
+
+// formatHTMLMath 公式按 MathJax、KaTeX 默认识别的 \\(...\\) 和 \\[...\\] 输出 TeX 源码
+func formatHTMLMath(piece parse.Piece) string {
+	if isDisplayMath(piece) {
+		return "<div class=\"math display\">\\[" + html.EscapeString(mathTeX(piece)) + "\\]</div>"
+	}
+	return "<span class=\"math inline\">\\(" + html.EscapeString(mathTeX(piece)) + "\\)</span>"
+}
+
+// htmlIDAttr piece 带有 id 属性时（如 epub 目录要链接到的标题）输出 id
+func htmlIDAttr(piece parse.Piece) string {
+	if id := piece.Attrs["id"]; id != "" {
+		return " id=\"" + html.EscapeString(id) + "\""
+	}
+	return ""
+}
+
+// 修饰标记对应的html标签，按由内到外的顺序
+var markTags = []struct {
+	mark string
+	tag  string
+}{
+	{parse.MARK_CODE, "code"},
+	{parse.MARK_SUP, "sup"},
+	{parse.MARK_SUB, "sub"},
+	{parse.MARK_UNDERLINE, "u"},
+	{parse.MARK_HIGHLIGHT, "mark"},
+	{parse.MARK_STRIKE, "del"},
+	{parse.MARK_ITALIC, "em"},
+	{parse.MARK_BOLD, "strong"},
+}
+
+func formatHTMLInlineText(piece parse.Piece) string {
+	text := html.EscapeString(blankReg.ReplaceAllString(piece.Val.(string), " "))
+	marks := defaultMarks[piece.Type]
+	if piece.Attrs["marks"] != "" {
+		marks = strings.Split(piece.Attrs["marks"], ",")
+	}
+	has := make(map[string]bool)
+	for _, m := range marks {
+		has[m] = true
+	}
+	for _, mt := range markTags {
+		if has[mt.mark] {
+			text = "<" + mt.tag + ">" + text + "</" + mt.tag + ">"
+		}
+	}
+	return text
+}
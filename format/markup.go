@@ -0,0 +1,375 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// markupDialect Org、AsciiDoc、reST 等轻量标记语言各自的语法，
+// 遍历 pieces、分段落、处理图片策略等由 markupWriter 统一完成
+type markupDialect interface {
+	// ext 输出文件的扩展名
+	ext() string
+	// document 加上标题、作者、日期等文档头
+	document(article parse.Article, body string) string
+	// header level 已经换算成从 1 开始、不跳级的相对级别
+	header(text string, level int) string
+	// inline 按修饰标记包裹文字，text 已经转义过，首尾没有空白
+	inline(text string, marks map[string]bool) string
+	// separator 行内标记紧挨着字符 r 时需要插入的分隔符，不需要时返回空字符串
+	separator(r rune, link bool) string
+	escape(text string) string
+	link(text string, href string) string
+	// image 独占一段的图片，src 为链接、本地文件名或 data URI
+	image(alt string, src string) string
+	// inlineImage 表格单元格等行内位置的图片
+	inlineImage(alt string, src string) string
+	codeBlock(rows []string, lang string) string
+	// math 公式，display 为 true 时是独占一段的公式
+	math(tex string, display bool) string
+	quote(body string, depth int) string
+	// list depth 为列表的嵌套层级，从 1 开始
+	list(items []markupItem, ordered bool, depth int) string
+	table(rows [][]markupCell, hasHeader bool, cols int) string
+	hr() string
+}
+
+// markupBlock 一个块，list 表示它是嵌套的列表
+type markupBlock struct {
+	text string
+	list bool
+}
+
+// markupItem 列表项，number 为有序列表项的编号
+type markupItem struct {
+	number string
+	blocks []markupBlock
+}
+
+// markupCell 表格单元格，被跨行/跨列占住的位置 covered 为 true
+type markupCell struct {
+	text    string
+	colSpan int
+	rowSpan int
+	header  bool
+	covered bool
+}
+
+// markupRenderer 用 dialect 输出文章，图片的处理方式与 Markdown 相同：
+// 链接原样引用，保存到本地的图片作为附件，base64 图片以 data URI 内嵌
+type markupRenderer struct {
+	dialect markupDialect
+}
+
+func (r markupRenderer) Render(article parse.Article, opts Options) ([]byte, map[string][]byte, error) {
+	w := &markupWriter{d: r.dialect, images: make(map[string][]byte), minLevel: minHeaderLevel(article.Content)}
+	blocks := w.blocks(article.Content, 0)
+	if url := article.Metadata.URL; url != "" {
+		blocks = append(blocks, markupBlock{text: r.dialect.escape("原文链接：") + r.dialect.link(url, url)})
+	}
+	for _, link := range sourceLinks(article.Metadata) {
+		text := r.dialect.escape(link.text)
+		if link.url != "" {
+			text = r.dialect.link(link.text, link.url)
+		}
+		blocks = append(blocks, markupBlock{text: r.dialect.escape(link.label+"：") + text})
+	}
+	var texts []string
+	for _, block := range blocks {
+		texts = append(texts, block.text)
+	}
+	return []byte(r.dialect.document(article, strings.Join(texts, "\n\n"))), w.images, nil
+}
+
+func (r markupRenderer) Ext() string {
+	return r.dialect.ext()
+}
+
+type markupWriter struct {
+	d         markupDialect
+	images    map[string][]byte
+	minLevel  int
+	lastLevel int
+	listDepth int
+}
+
+// minHeaderLevel 正文中最高的标题级别，没有标题时为 0
+func minHeaderLevel(pieces []parse.Piece) int {
+	var min int
+	for _, piece := range pieces {
+		var level int
+		switch piece.Type {
+		case parse.HEADER:
+			level, _ = strconv.Atoi(piece.Attrs["level"])
+		case parse.BLOCK_QUOTES, parse.O_LIST, parse.U_LIST:
+			level = minHeaderLevel(piece.Val.([]parse.Piece))
+		}
+		if level > 0 && (min == 0 || level < min) {
+			min = level
+		}
+	}
+	return min
+}
+
+// blocks 行内内容以换行为界分成段落，图片单独成段
+func (w *markupWriter) blocks(pieces []parse.Piece, quoteDepth int) []markupBlock {
+	var blocks []markupBlock
+	var inline []parse.Piece
+	flush := func() {
+		var run []parse.Piece
+		for _, piece := range append(inline, parse.Piece{Type: parse.NULL}) {
+			if piece.Type == parse.IMAGE || piece.Type == parse.IMAGE_BASE64 || piece.Type == parse.NULL {
+				if text := strings.TrimSpace(w.inline(run)); text != "" && !isBlankText(text) {
+					blocks = append(blocks, markupBlock{text: text})
+				}
+				run = nil
+				if piece.Type != parse.NULL {
+					blocks = append(blocks, markupBlock{text: w.d.image(piece.Attrs["alt"], w.imageSrc(piece))})
+				}
+				continue
+			}
+			run = append(run, piece)
+		}
+		inline = nil
+	}
+	for i := 0; i < len(pieces); i++ {
+		piece := pieces[i]
+		if isHTMLInline(piece) {
+			inline = append(inline, piece)
+			continue
+		}
+		flush()
+		switch piece.Type {
+		case parse.HEADER:
+			blocks = append(blocks, markupBlock{text: w.d.header(w.d.escape(strings.TrimSpace(piece.Val.(string))), w.headerLevel(piece))})
+		case parse.CODE_BLOCK:
+			blocks = append(blocks, markupBlock{text: w.d.codeBlock(piece.Val.([]string), piece.Attrs["lang"])})
+		case parse.MATH:
+			blocks = append(blocks, markupBlock{text: w.d.math(mathTeX(piece), true)})
+		case parse.BLOCK_QUOTES:
+			var texts []string
+			for _, block := range w.blocks(piece.Val.([]parse.Piece), quoteDepth+1) {
+				texts = append(texts, block.text)
+			}
+			if len(texts) > 0 {
+				blocks = append(blocks, markupBlock{text: w.d.quote(strings.Join(texts, "\n\n"), quoteDepth)})
+			}
+		case parse.O_LIST, parse.U_LIST:
+			// 相邻的同类列表项放进同一个列表里
+			var items []markupItem
+			w.listDepth++
+			for ; i < len(pieces) && pieces[i].Type == piece.Type; i++ {
+				items = append(items, markupItem{number: pieces[i].Attrs["number"], blocks: w.blocks(pieces[i].Val.([]parse.Piece), quoteDepth)})
+			}
+			w.listDepth--
+			i--
+			blocks = append(blocks, markupBlock{text: w.d.list(items, piece.Type == parse.O_LIST, w.listDepth+1), list: true})
+		case parse.TABLE:
+			if table, ok := piece.Val.(parse.Table); ok && len(table.Rows) > 0 {
+				blocks = append(blocks, markupBlock{text: w.table(table)})
+			}
+		case parse.VIDEO:
+			link := firstNonEmpty(piece.Attrs["src"], piece.Attrs["embed"], piece.Attrs["file"])
+			blocks = append(blocks, markupBlock{text: w.linkOrText("▶ "+mediaTitle(piece, "视频"), link)})
+		case parse.AUDIO:
+			blocks = append(blocks, markupBlock{text: w.linkOrText("♪ "+mediaTitle(piece, "音频"), piece.Attrs["src"])})
+		case parse.EMBED_CARD:
+			text := w.linkOrText(firstNonEmpty(piece.Attrs["title"], piece.Attrs["url"]), piece.Attrs["url"])
+			if desc := piece.Attrs["desc"]; desc != "" {
+				text += "\n\n" + w.d.escape(desc)
+			}
+			blocks = append(blocks, markupBlock{text: w.d.quote(text, quoteDepth)})
+		case parse.HR:
+			blocks = append(blocks, markupBlock{text: w.d.hr()})
+		}
+	}
+	flush()
+	return blocks
+}
+
+// headerLevel 换算成以正文最高级标题为 1 的级别，并且不比上一个标题多跳一级以上
+func (w *markupWriter) headerLevel(piece parse.Piece) int {
+	level, _ := strconv.Atoi(piece.Attrs["level"])
+	level = level - w.minLevel + 1
+	if level < 1 {
+		level = 1
+	}
+	if level > w.lastLevel+1 {
+		level = w.lastLevel + 1
+	}
+	w.lastLevel = level
+	return level
+}
+
+func (w *markupWriter) linkOrText(text string, href string) string {
+	if href == "" {
+		return w.d.escape(text)
+	}
+	return w.d.link(text, href)
+}
+
+// imageSrc 保存到本地的图片记为附件，返回文件名；base64 图片返回 data URI
+func (w *markupWriter) imageSrc(piece parse.Piece) string {
+	switch {
+	case piece.Type == parse.IMAGE_BASE64:
+		return imageDataURI(piece)
+	case piece.Val != nil:
+		name := imageFileName(piece)
+		w.images[name] = piece.Val.([]byte)
+		return name
+	default:
+		return piece.Attrs["src"]
+	}
+}
+
+// inline 输出行内内容；行内标记紧挨着文字时按 dialect 的要求插入分隔符
+func (w *markupWriter) inline(pieces []parse.Piece) string {
+	var text string
+	// markedEnd 文字是否以行内标记结尾，linkEnd 是否以链接结尾
+	var markedEnd, linkEnd bool
+	appendMarked := func(marked string, link bool) {
+		if last, _ := utf8.DecodeLastRuneInString(text); text != "" {
+			text += w.d.separator(last, link)
+		}
+		text += marked
+		markedEnd, linkEnd = true, link
+	}
+	appendText := func(plain string) {
+		if first, _ := utf8.DecodeRuneInString(plain); markedEnd && plain != "" {
+			text += w.d.separator(first, linkEnd)
+		}
+		text += plain
+		markedEnd, linkEnd = false, false
+	}
+	for _, piece := range pieces {
+		switch piece.Type {
+		case parse.NORMAL_TEXT:
+			appendText(w.d.escape(blankReg.ReplaceAllString(piece.Val.(string), " ")))
+		case parse.BOLD_TEXT, parse.ITALIC_TEXT, parse.BOLD_ITALIC_TEXT,
+			parse.STRIKETHROUGH_TEXT, parse.UNDERLINE_TEXT, parse.HIGHLIGHT_TEXT, parse.SUP_TEXT, parse.SUB_TEXT,
+			parse.CODE_INLINE:
+			raw := blankReg.ReplaceAllString(piece.Val.(string), " ")
+			core := strings.TrimSpace(raw)
+			if core == "" {
+				appendText(raw)
+				continue
+			}
+			lead := raw[:strings.Index(raw, core)]
+			trail := raw[len(lead)+len(core):]
+			if lead != "" {
+				appendText(lead)
+			}
+			marks := defaultMarks[piece.Type]
+			if piece.Attrs["marks"] != "" {
+				marks = strings.Split(piece.Attrs["marks"], ",")
+			}
+			has := make(map[string]bool)
+			for _, m := range marks {
+				has[m] = true
+			}
+			if !has[parse.MARK_CODE] {
+				core = w.d.escape(core)
+			}
+			appendMarked(w.d.inline(core, has), false)
+			if trail != "" {
+				appendText(trail)
+			}
+		case parse.LINK:
+			appendMarked(w.d.link(strings.TrimSpace(piece.Val.(string)), piece.Attrs["href"]), true)
+		case parse.MATH:
+			appendMarked(w.d.math(mathTeX(piece), false), false)
+		case parse.IMAGE, parse.IMAGE_BASE64:
+			appendMarked(w.d.inlineImage(piece.Attrs["alt"], w.imageSrc(piece)), true)
+		case parse.BR:
+			appendText(" ")
+		}
+	}
+	return text
+}
+
+// table 跨行/跨列的单元格展开到网格上，被占住的位置标记为 covered
+func (w *markupWriter) table(table parse.Table) string {
+	cols := table.ColCount()
+	var rows [][]markupCell
+	covered := make(map[[2]int]bool)
+	for r, row := range table.Rows {
+		var cells []markupCell
+		c := 0
+		for _, cell := range row {
+			for covered[[2]int{r, c}] {
+				cells = append(cells, markupCell{covered: true})
+				c++
+			}
+			colSpan, rowSpan := cell.ColSpan, cell.RowSpan
+			if colSpan < 1 {
+				colSpan = 1
+			}
+			if rowSpan < 1 {
+				rowSpan = 1
+			}
+			var cellPieces []parse.Piece
+			for _, piece := range cell.Content {
+				if isHTMLInline(piece) || piece.Type == parse.BR {
+					cellPieces = append(cellPieces, piece)
+				} else {
+					// 单元格里的块级内容只保留文字
+					cellPieces = append(cellPieces, parse.Piece{Type: parse.NORMAL_TEXT, Val: " " + formatText([]parse.Piece{piece}) + " "})
+				}
+			}
+			cells = append(cells, markupCell{text: strings.TrimSpace(w.inline(cellPieces)), colSpan: colSpan, rowSpan: rowSpan, header: cell.IsHeader})
+			for dr := 0; dr < rowSpan; dr++ {
+				for dc := 0; dc < colSpan; dc++ {
+					if dr > 0 || dc > 0 {
+						covered[[2]int{r + dr, c + dc}] = true
+					}
+				}
+			}
+			c++
+			for dc := 1; dc < colSpan; dc++ {
+				cells = append(cells, markupCell{covered: true})
+				c++
+			}
+		}
+		for ; c < cols; c++ {
+			cells = append(cells, markupCell{covered: covered[[2]int{r, c}]})
+		}
+		rows = append(rows, cells)
+	}
+	return w.d.table(rows, table.HasHeader, cols)
+}
+
+// indentLines 除第一行外的每行缩进 indent，空行保持为空
+func indentLines(text string, first string, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line != "":
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// displayWidth 文字的显示宽度，全角字符算两个
+func displayWidth(text string) int {
+	var width int
+	for _, r := range text {
+		if r >= 0x1100 && (unicode.Is(unicode.Han, r) || unicode.In(r, unicode.Hangul, unicode.Hiragana, unicode.Katakana) || r >= 0x3000 && r <= 0x303F || r >= 0xFF00 && r <= 0xFF60) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// isSpaceOrPunct 空白和标点旁边的行内标记不需要分隔符
+func isSpaceOrPunct(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r)
+}
@@ -0,0 +1,90 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// 看起来像标记的文字
+var markupLikeTexts = []string{
+	"* 不是标题", "** 也不是", "#+TITLE: 假的关键字", "# 不是注释", "| a | b |", "-----", "- 不是列表", "+ 不是列表", "1. 不是列表", "2) 不是列表",
+	"*不是粗体*", "/不是斜体/", "a /usr/bin/ b", "snake_case 和 _不是下划线_", "C++ 和 +不是删除线+", "=不是代码= ~也不是~",
+	"= 不是标题", "== 不是标题", ". 不是列表", ".不是块标题", "|===", "----", "____", "// 不是注释", "'''", ":不是属性: 值", "[不是属性]",
+	"NOTE: 不是提示", "{author} 不是属性引用", "a]b [c]", "`不是代码`", "#不是高亮#", "^不是上标^", "a <<不是交叉引用>>", "结尾的反斜杠\\",
+	"普通的文字，没有标记",
+}
+
+// TestMarkupEscapeRoundTrip 转义后能还原出原文，去掉转义了的部分后不再有标记
+func TestMarkupEscapeRoundTrip(t *testing.T) {
+	passReg := regexp.MustCompile(`pass:c\[((?:[^\]\\]|\\.)*)\]`)
+	backslashReg := regexp.MustCompile(`\\(.)`)
+	tests := []struct {
+		name     string
+		dialect  markupDialect
+		unescape func(string) string
+		// strip 去掉转义了的部分
+		strip func(string) string
+		// markup 剩下的文字里不应出现的标记
+		markup *regexp.Regexp
+	}{
+		{
+			"org", orgDialect{},
+			func(s string) string { return strings.ReplaceAll(s, "\u200b", "") },
+			func(s string) string { return s },
+			regexp.MustCompile(`^(?:\*+\s|#\+|#\s|\||-{5}|[-+]\s|\d+[.)]\s)|(?:^|[\s\-('"{])[*/_=~+]\S`),
+		},
+		{
+			"asciidoc", asciidocDialect{},
+			func(s string) string {
+				s = passReg.ReplaceAllStringFunc(s, func(m string) string {
+					return strings.ReplaceAll(passReg.FindStringSubmatch(m)[1], `\]`, "]")
+				})
+				return strings.ReplaceAll(s, "{backslash}", `\`)
+			},
+			func(s string) string { return strings.ReplaceAll(passReg.ReplaceAllString(s, "x"), "{backslash}", "") },
+			regexp.MustCompile("^(?:=|[*.-]+\\s|\\.|\\|===|-{4}|_{4}|//|'''|:|\\[|NOTE:|\\d+\\.\\s)|[*_`#^~+{\\[]|<<"),
+		},
+		{
+			"rst", rstDialect{},
+			func(s string) string { return backslashReg.ReplaceAllString(s, "$1") },
+			func(s string) string { return backslashReg.ReplaceAllString(s, "") },
+			regexp.MustCompile("[*`|_]"),
+		},
+	}
+	for _, tt := range tests {
+		for _, text := range markupLikeTexts {
+			escaped := tt.dialect.escape(text)
+			if got := tt.unescape(escaped); got != text {
+				t.Errorf("%s: escape(%q) = %q does not round-trip: %q", tt.name, text, escaped, got)
+			}
+			if m := tt.markup.FindString(tt.strip(escaped)); m != "" {
+				t.Errorf("%s: escape(%q) = %q still contains markup %q", tt.name, text, escaped, m)
+			}
+		}
+	}
+}
+
+func TestMarkupEscapeInDocument(t *testing.T) {
+	article := parse.Article{
+		Title:   header("1", "标题"),
+		Content: []parse.Piece{text("* 不是标题"), {Type: parse.BR}, text("= 也不是"), {Type: parse.BR}, {Type: parse.BOLD_TEXT, Val: "a*b"}},
+	}
+	for _, name := range []string{"org", "asciidoc"} {
+		r, err := GetRenderer(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, _, err := r.Render(article, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "= 也") {
+				t.Errorf("%s: line %q is markup", name, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// mathArticle 行内公式、独占一行的公式（源码中带空行）和表格里的公式，段落间的换行与解析的结果一致
+func mathArticle() parse.Article {
+	inline := func(tex string) parse.Piece { return parse.Piece{Type: parse.MATH, Val: tex} }
+	display := func(tex string) parse.Piece {
+		return parse.Piece{Type: parse.MATH, Val: tex, Attrs: map[string]string{"display": "true"}}
+	}
+	mathCell := parse.TableCell{Content: []parse.Piece{inline("a\n+b")}, ColSpan: 1, RowSpan: 1}
+	br := parse.Piece{Type: parse.BR}
+	return parse.Article{
+		Title: header("1", "公式"),
+		Content: []parse.Piece{
+			text("设 "), inline(" x^2 < y "), text(" 为正，则"), br,
+			display("\\begin{aligned}\n  f(x) &= x^2 \\\\\n\n  g(x) &= 2x\n\\end{aligned}"), br,
+			text("其中 "), inline("\\alpha"), text(" 为常数。"), br,
+			{Type: parse.TABLE, Val: parse.Table{HasHeader: true, Rows: []parse.TableRow{
+				{headerCell("式子"), headerCell("值")},
+				{mathCell, cell("1")},
+			}}},
+		},
+	}
+}
+
+func TestRenderMath(t *testing.T) {
+	for _, name := range []string{"markdown", "text", "html", "org", "asciidoc", "rst", "docx"} {
+		t.Run(name, func(t *testing.T) {
+			renderArticleGolden(t, name, mathArticle(), "math")
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package format
+
+import (
+	"html"
+	"strconv"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// formatVideo 视频：默认输出带封面的链接；MEDIA_STYLE_HTML5 时有视频文件用 video 标签，能嵌入播放器的用 iframe
+func formatVideo(piece parse.Piece, opts *Options) string {
+	if opts.MediaStyle == MEDIA_STYLE_HTML5 {
+		if videoHTML := formatVideoHTML(piece); videoHTML != "" {
+			return "\n" + videoHTML + "\n\n"
+		}
+	}
+	title := mediaTitle(piece, "视频")
+	src, poster := piece.Attrs["src"], piece.Attrs["poster"]
+	var mdStr string
+	switch {
+	case poster != "" && src != "":
+		mdStr = "[![" + title + "](" + poster + ")](" + src + ")"
+	case poster != "":
+		mdStr = "![" + title + "](" + poster + ")"
+	case src != "":
+		mdStr = "[▶ " + title + "](" + src + ")"
+	default:
+		mdStr = "▶ " + title
+	}
+	return mdStr + opts.profile().lineBreak
+}
+
+// formatAudio 音频：默认输出链接；MEDIA_STYLE_HTML5 时用 audio 标签
+func formatAudio(piece parse.Piece, opts *Options) string {
+	title := mediaTitle(piece, "音频")
+	if opts.MediaStyle == MEDIA_STYLE_HTML5 && piece.Attrs["src"] != "" {
+		return "\n" + formatAudioHTML(piece) + "\n\n"
+	}
+	if src := piece.Attrs["src"]; src != "" {
+		return "[♪ " + title + "](" + src + ")" + opts.profile().lineBreak
+	}
+	return "♪ " + title + opts.profile().lineBreak
+}
+
+// formatEmbedCard 小程序、公众号名片等卡片输出成引用块，方言支持 callout 时用 callout
+func formatEmbedCard(piece parse.Piece, opts *Options) string {
+	p := opts.profile()
+	var label string
+	switch piece.Attrs["kind"] {
+	case "miniprogram":
+		label = "[小程序] "
+	case "profile":
+		label = "[公众号] "
+	}
+	title := piece.Attrs["title"]
+	if title == "" {
+		title = piece.Attrs["url"]
+	}
+	if url := piece.Attrs["url"]; url != "" {
+		title = "[" + title + "](" + url + ")"
+	}
+	cardMdStr := label + title + p.lineBreak
+	if desc := piece.Attrs["desc"]; desc != "" {
+		cardMdStr += desc + p.lineBreak
+	}
+	if image := piece.Attrs["image"]; image != "" {
+		cardMdStr += "![](" + image + ")" + p.lineBreak
+	}
+	return p.formatQuote(cardMdStr, p.cardCallout) + "\n"
+}
+
+func mediaTitle(piece parse.Piece, defaultTitle string) string {
+	title := piece.Attrs["title"]
+	if title == "" {
+		title = defaultTitle
+	}
+	if duration, err := strconv.Atoi(piece.Attrs["duration"]); err == nil && duration > 0 {
+		title += " (" + formatDuration(duration) + ")"
+	}
+	return title
+}
+
+// formatDuration 秒数 => mm:ss 或 h:mm:ss
+func formatDuration(seconds int) string {
+	h, m, sec := seconds/3600, seconds%3600/60, seconds%60
+	pad := func(n int) string {
+		if n < 10 {
+			return "0" + strconv.Itoa(n)
+		}
+		return strconv.Itoa(n)
+	}
+	if h > 0 {
+		return strconv.Itoa(h) + ":" + pad(m) + ":" + pad(sec)
+	}
+	return pad(m) + ":" + pad(sec)
+}
+
+// formatVideoHTML 没有可播放的视频文件和可嵌入的播放器时返回空字符串
+func formatVideoHTML(piece parse.Piece) string {
+	if file := piece.Attrs["file"]; file != "" {
+		videoHTML := "<video controls=\"controls\" src=\"" + html.EscapeString(file) + "\""
+		if poster := piece.Attrs["poster"]; poster != "" {
+			videoHTML += " poster=\"" + html.EscapeString(poster) + "\""
+		}
+		return videoHTML + "></video>"
+	}
+	if embed := piece.Attrs["embed"]; embed != "" {
+		return "<iframe src=\"" + html.EscapeString(embed) + "\" frameborder=\"0\" allowfullscreen=\"allowfullscreen\"></iframe>"
+	}
+	return ""
+}
+
+func formatAudioHTML(piece parse.Piece) string {
+	return "<audio controls=\"controls\" src=\"" + html.EscapeString(piece.Attrs["src"]) + "\" title=\"" + html.EscapeString(mediaTitle(piece, "音频")) + "\"></audio>"
+}
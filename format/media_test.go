@@ -0,0 +1,78 @@
+package format
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestFormatMedia(t *testing.T) {
+	video := parse.Piece{Type: parse.VIDEO, Attrs: map[string]string{
+		"title": "演示", "duration": "3725", "src": "https://v.qq.com/x/page/a.html",
+		"poster": "https://a.com/p.jpg", "embed": "https://v.qq.com/txp/iframe/player.html?vid=a",
+	}}
+	fileVideo := parse.Piece{Type: parse.VIDEO, Attrs: map[string]string{"file": "https://a.com/v.mp4", "duration": "65"}}
+	audio := parse.Piece{Type: parse.AUDIO, Attrs: map[string]string{"title": "歌", "src": "https://a.com/a.mp3"}}
+	card := parse.Piece{Type: parse.EMBED_CARD, Attrs: map[string]string{
+		"kind": "miniprogram", "title": "小程序名", "url": "https://a.com/m", "desc": "说明",
+	}}
+	tests := []struct {
+		name  string
+		piece parse.Piece
+		style MediaStyle
+		want  string
+	}{
+		{"video link", video, MEDIA_STYLE_LINK, "[![演示 (1:02:05)](https://a.com/p.jpg)](https://v.qq.com/x/page/a.html)  \n"},
+		{"video iframe", video, MEDIA_STYLE_HTML5, "\n<iframe src=\"https://v.qq.com/txp/iframe/player.html?vid=a\" frameborder=\"0\" allowfullscreen=\"allowfullscreen\"></iframe>\n\n"},
+		{"video file", fileVideo, MEDIA_STYLE_HTML5, "\n<video controls=\"controls\" src=\"https://a.com/v.mp4\"></video>\n\n"},
+		{"video without link", fileVideo, MEDIA_STYLE_LINK, "▶ 视频 (01:05)  \n"},
+		{"audio link", audio, MEDIA_STYLE_LINK, "[♪ 歌](https://a.com/a.mp3)  \n"},
+		{"audio tag", audio, MEDIA_STYLE_HTML5, "\n<audio controls=\"controls\" src=\"https://a.com/a.mp3\" title=\"歌\"></audio>\n\n"},
+		{"card", card, MEDIA_STYLE_LINK, "> [小程序] [小程序名](https://a.com/m)  \n> 说明  \n\n"},
+	}
+	for _, tt := range tests {
+		got, _ := formatContent([]parse.Piece{tt.piece}, 0, &Options{MediaStyle: tt.style})
+		if got != tt.want {
+			t.Errorf("%s:\n got: %q\nwant: %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImageFileName(t *testing.T) {
+	png := pngData(t)
+	tests := []struct {
+		name  string
+		piece parse.Piece
+		ext   string
+	}{
+		{"type from content", parse.Piece{Type: parse.IMAGE, Val: png, Attrs: map[string]string{"src": "https://a.com/x?wx_fmt=jpeg"}}, ".png"},
+		{"recorded type", parse.Piece{Type: parse.IMAGE, Val: []byte("RIFF"), Attrs: map[string]string{parse.IMAGE_ATTR_TYPE: "image/webp"}}, ".webp"},
+		{"wx_fmt", parse.Piece{Type: parse.IMAGE, Val: []byte("?"), Attrs: map[string]string{"src": "https://a.com/x?wx_fmt=gif"}}, ".gif"},
+		{"unknown", parse.Piece{Type: parse.IMAGE, Val: []byte("?"), Attrs: map[string]string{"src": "https://a.com/x"}}, ".png"},
+	}
+	for _, tt := range tests {
+		if got := imageFileName(tt.piece); !strings.HasSuffix(got, tt.ext) || got != md5Hex(tt.piece.Val.([]byte))+tt.ext {
+			t.Errorf("%s: imageFileName = %q, want md5%s", tt.name, got, tt.ext)
+		}
+	}
+}
+
+func TestImageDataURI(t *testing.T) {
+	gif := base64.StdEncoding.EncodeToString([]byte("GIF89a\x01\x00\x01\x00"))
+	tests := []struct {
+		name  string
+		piece parse.Piece
+		want  string
+	}{
+		{"type from content", parse.Piece{Type: parse.IMAGE_BASE64, Val: gif}, "data:image/gif;base64," + gif},
+		{"recorded type", parse.Piece{Type: parse.IMAGE_BASE64, Val: "AAAA", Attrs: map[string]string{parse.IMAGE_ATTR_TYPE: "image/svg+xml"}}, "data:image/svg+xml;base64,AAAA"},
+		{"unknown", parse.Piece{Type: parse.IMAGE_BASE64, Val: "AAAA"}, "data:image/png;base64,AAAA"},
+	}
+	for _, tt := range tests {
+		if got := imageDataURI(tt.piece); got != tt.want {
+			t.Errorf("%s: imageDataURI = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// orgDialect Emacs org-mode
+type orgDialect struct{}
+
+func (orgDialect) ext() string {
+	return "org"
+}
+
+func (d orgDialect) document(article parse.Article, body string) string {
+	title, _ := article.Title.Val.(string)
+	head := "#+TITLE: " + d.escape(title) + "\n"
+	md := article.Metadata
+	if author := firstNonEmpty(md.Author, md.AccountName); author != "" {
+		head += "#+AUTHOR: " + d.escape(author) + "\n"
+	}
+	if !md.PublishTime.IsZero() {
+		head += "#+DATE: " + md.PublishTime.Format("2006-01-02") + "\n"
+	}
+	if tags := splitTags(article.Tags); len(tags) > 0 {
+		head += "#+FILETAGS: :" + strings.Join(tags, ":") + ":\n"
+	}
+	return head + "\n" + body + "\n"
+}
+
+func (orgDialect) header(text string, level int) string {
+	return strings.Repeat("*", level) + " " + text
+}
+
+func (orgDialect) inline(text string, marks map[string]bool) string {
+	if marks[parse.MARK_CODE] {
+		text = "~" + text + "~"
+	}
+	if marks[parse.MARK_SUP] {
+		text = "^{" + text + "}"
+	}
+	if marks[parse.MARK_SUB] {
+		text = "_{" + text + "}"
+	}
+	if marks[parse.MARK_UNDERLINE] {
+		text = "_" + text + "_"
+	}
+	if marks[parse.MARK_STRIKE] {
+		text = "+" + text + "+"
+	}
+	if marks[parse.MARK_ITALIC] {
+		text = "/" + text + "/"
+	}
+	if marks[parse.MARK_BOLD] {
+		text = "*" + text + "*"
+	}
+	return text
+}
+
+// separator org 的强调标记前后只能是空白或少数几个标点，中文旁边用零宽空格隔开
+func (orgDialect) separator(r rune, link bool) string {
+	if link || r < 0x80 && isSpaceOrPunct(r) {
+		return ""
+	}
+	return "\u200b"
+}
+
+// orgLineStart 行首的这些内容会被当成标题、关键字、注释、表格、分隔线或列表
+var orgLineStart = regexp.MustCompile(`^\s*(?:\*+(?:\s|$)|#\+|#(?:\s|$)|\||-{5,}|[-+]\s|\d+[.)]\s)`)
+
+// escape org 没有转义字符，用零宽空格打断标记：行首的标题、列表等标记前面加一个，
+// 前面是空白或 -('"{ 时可能开始强调的 * / _ = ~ + 前面也加一个
+func (orgDialect) escape(text string) string {
+	if orgLineStart.MatchString(text) {
+		i := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+		text = text[:i] + "\u200b" + text[i:]
+	}
+	var b strings.Builder
+	prev := ' '
+	for _, r := range text {
+		if strings.ContainsRune("*/_=~+", r) && (unicode.IsSpace(prev) || strings.ContainsRune("-('\"{", prev)) {
+			b.WriteString("\u200b")
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+func (orgDialect) link(text string, href string) string {
+	text = strings.NewReplacer("[", "{", "]", "}").Replace(text)
+	href = strings.NewReplacer("[", "%5B", "]", "%5D").Replace(href)
+	if text == "" || text == href {
+		return "[[" + href + "]]"
+	}
+	return "[[" + href + "][" + text + "]]"
+}
+
+func (d orgDialect) image(alt string, src string) string {
+	if alt != "" {
+		return "#+CAPTION: " + alt + "\n" + d.inlineImage(alt, src)
+	}
+	return d.inlineImage(alt, src)
+}
+
+// inlineImage 不带描述的链接在 org 里显示为图片，本地文件要加 file: 前缀
+func (orgDialect) inlineImage(alt string, src string) string {
+	if !strings.Contains(src, ":") {
+		src = "file:" + src
+	}
+	return "[[" + src + "]]"
+}
+
+// codeBlock 以 * 或 #+ 开头的行要用逗号转义
+func (orgDialect) codeBlock(rows []string, lang string) string {
+	var lines []string
+	for _, row := range rows {
+		if strings.HasPrefix(row, "*") || strings.HasPrefix(strings.TrimLeft(row, " \t"), "#+") {
+			row = "," + row
+		}
+		lines = append(lines, row)
+	}
+	head := "#+BEGIN_SRC"
+	if lang != "" {
+		head += " " + lang
+	}
+	return head + "\n" + strings.Join(lines, "\n") + "\n#+END_SRC"
+}
+
+// math 行内公式 \(...\)，独占一段的公式 \[...\]
+func (orgDialect) math(tex string, display bool) string {
+	if display {
+		return "\\[\n" + tex + "\n\\]"
+	}
+	return "\\(" + tex + "\\)"
+}
+
+func (orgDialect) quote(body string, depth int) string {
+	return "#+BEGIN_QUOTE\n" + body + "\n#+END_QUOTE"
+}
+
+func (orgDialect) list(items []markupItem, ordered bool, depth int) string {
+	var texts []string
+	for i, item := range items {
+		marker := "- "
+		if ordered {
+			number := firstNonEmpty(item.number, "1")
+			marker = number + ". "
+			if i == 0 && number != "1" {
+				marker += "[@" + number + "] "
+			}
+		}
+		var blocks []string
+		for _, block := range item.blocks {
+			blocks = append(blocks, block.text)
+		}
+		indent := strings.Repeat(" ", len(marker))
+		if i == 0 && ordered && strings.Contains(marker, "[@") {
+			indent = strings.Repeat(" ", strings.Index(marker, "[@"))
+		}
+		texts = append(texts, indentLines(strings.Join(blocks, "\n\n"), marker, indent))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// table org 表格不支持合并单元格，被占住的位置留空
+func (orgDialect) table(rows [][]markupCell, hasHeader bool, cols int) string {
+	var lines []string
+	for i, row := range rows {
+		var cells []string
+		for _, cell := range row {
+			cells = append(cells, strings.ReplaceAll(cell.text, "|", "\\vert{}"))
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 && hasHeader {
+			lines = append(lines, "|"+strings.Repeat("---+", cols-1)+"---|")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (orgDialect) hr() string {
+	return "-----"
+}
@@ -0,0 +1,74 @@
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// Renderer 把解析好的文章输出成某种格式
+type Renderer interface {
+	// Render 返回输出的文件内容，以及需要一起保存的附件（文件名 => 内容，如本地保存的图片）
+	Render(article parse.Article, opts Options) ([]byte, map[string][]byte, error)
+	// Ext 输出文件的扩展名，不带点
+	Ext() string
+}
+
+// DefaultRendererName 未指定输出格式时使用的 renderer
+const DefaultRendererName = "markdown"
+
+var renderers = make(map[string]Renderer)
+
+// RegisterRenderer 按名字注册 renderer，同名的会被覆盖
+func RegisterRenderer(name string, r Renderer) {
+	renderers[strings.ToLower(name)] = r
+}
+
+// GetRenderer 按名字取 renderer，name 为空时返回默认的 markdown
+func GetRenderer(name string) (Renderer, error) {
+	if name == "" {
+		name = DefaultRendererName
+	}
+	r, ok := renderers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, available: %s", name, strings.Join(RendererNames(), ", "))
+	}
+	return r, nil
+}
+
+// RendererNames 已注册的 renderer 名字
+func RendererNames() []string {
+	var names []string
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterRenderer("markdown", markdownRenderer{})
+	RegisterRenderer("md", markdownRenderer{})
+	RegisterRenderer("text", textRenderer{})
+	RegisterRenderer("txt", textRenderer{})
+	RegisterRenderer("html", htmlRenderer{})
+	RegisterRenderer("epub", epubRenderer{})
+	RegisterRenderer("docx", docxRenderer{})
+	RegisterRenderer("org", markupRenderer{orgDialect{}})
+	RegisterRenderer("asciidoc", markupRenderer{asciidocDialect{}})
+	RegisterRenderer("adoc", markupRenderer{asciidocDialect{}})
+	RegisterRenderer("rst", markupRenderer{rstDialect{}})
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) Render(article parse.Article, opts Options) ([]byte, map[string][]byte, error) {
+	result, saveImageBytes := FormatWithOptions(article, opts)
+	return []byte(result), saveImageBytes, nil
+}
+
+func (markdownRenderer) Ext() string {
+	return "md"
+}
@@ -0,0 +1,84 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestGetRenderer(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  string
+	}{
+		{"", "md"},
+		{"markdown", "md"},
+		{"MD", "md"},
+		{"text", "txt"},
+		{"html", "html"},
+		{"epub", "epub"},
+		{"docx", "docx"},
+		{"org", "org"},
+		{"adoc", "adoc"},
+		{"rst", "rst"},
+	}
+	for _, tt := range tests {
+		r, err := GetRenderer(tt.name)
+		if err != nil {
+			t.Errorf("GetRenderer(%q): %v", tt.name, err)
+			continue
+		}
+		if r.Ext() != tt.ext {
+			t.Errorf("GetRenderer(%q).Ext() = %q, want %q", tt.name, r.Ext(), tt.ext)
+		}
+	}
+	if _, err := GetRenderer("pdf"); err == nil || !strings.Contains(err.Error(), "markdown") {
+		t.Errorf("unknown format: err = %v, want an error listing the available formats", err)
+	}
+}
+
+// renderGolden 用 name 对应的 renderer 输出 sampleArticle，与 testdata/render/<name>.<ext> 比较
+func renderGolden(t *testing.T, name string) {
+	t.Helper()
+	renderArticleGolden(t, name, sampleArticle(), "render")
+}
+
+// renderArticleGolden 用 name 对应的 renderer 输出 article，与 testdata/<dir>/<name>.<ext> 比较
+func renderArticleGolden(t *testing.T, name string, article parse.Article, dir string) {
+	t.Helper()
+	r, err := GetRenderer(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _, err := r.Render(article, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ext() == "epub" || r.Ext() == "docx" {
+		// zip 格式的输出比较其中的文件
+		checkGolden(t, dir+"/"+name+"."+r.Ext()+".txt", zipDump(t, out))
+		return
+	}
+	checkGolden(t, dir+"/"+name+"."+r.Ext(), out)
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	renderGolden(t, "markdown")
+}
+
+func TestRenderText(t *testing.T) {
+	renderGolden(t, "text")
+}
+
+func TestRenderHTML(t *testing.T) {
+	renderGolden(t, "html")
+}
+
+func TestRenderMarkup(t *testing.T) {
+	for _, name := range []string{"org", "asciidoc", "rst"} {
+		t.Run(name, func(t *testing.T) {
+			renderGolden(t, name)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// rstDialect reStructuredText（docutils / Sphinx）
+type rstDialect struct{}
+
+// rstAdornments 各级标题的下划线字符，文档标题另外用上下两条 = 线
+var rstAdornments = []string{"=", "-", "~", "^", "\"", "'"}
+
+func (rstDialect) ext() string {
+	return "rst"
+}
+
+func (d rstDialect) document(article parse.Article, body string) string {
+	title, _ := article.Title.Val.(string)
+	title = d.escape(title)
+	line := strings.Repeat("=", displayWidth(title))
+	head := line + "\n" + title + "\n" + line + "\n\n"
+	md := article.Metadata
+	var fields string
+	if author := firstNonEmpty(md.Author, md.AccountName); author != "" {
+		fields += ":Author: " + d.escape(author) + "\n"
+	}
+	if !md.PublishTime.IsZero() {
+		fields += ":Date: " + md.PublishTime.Format("2006-01-02") + "\n"
+	}
+	if tags := splitTags(article.Tags); len(tags) > 0 {
+		fields += ":Keywords: " + d.escape(strings.Join(tags, ", ")) + "\n"
+	}
+	if fields != "" {
+		head += fields + "\n"
+	}
+	return head + body + "\n"
+}
+
+// header 下划线不能比标题文字短
+func (rstDialect) header(text string, level int) string {
+	if level > len(rstAdornments) {
+		level = len(rstAdornments)
+	}
+	return text + "\n" + strings.Repeat(rstAdornments[level-1], displayWidth(text))
+}
+
+// inline reST 的行内标记不能嵌套，按 代码 > 上下标 > 加粗 > 斜体 取一种，删除线和下划线没有对应的写法
+func (rstDialect) inline(text string, marks map[string]bool) string {
+	switch {
+	case marks[parse.MARK_CODE]:
+		return "``" + text + "``"
+	case marks[parse.MARK_SUP]:
+		return ":sup:`" + text + "`"
+	case marks[parse.MARK_SUB]:
+		return ":sub:`" + text + "`"
+	case marks[parse.MARK_BOLD]:
+		return "**" + text + "**"
+	case marks[parse.MARK_ITALIC]:
+		return "*" + text + "*"
+	}
+	return text
+}
+
+// separator 行内标记前后必须是空白或标点，紧挨文字时用转义的空格隔开，输出时不占位置
+func (rstDialect) separator(r rune, link bool) string {
+	if isSpaceOrPunct(r) {
+		return ""
+	}
+	return "\\ "
+}
+
+func (rstDialect) escape(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "*", "\\*", "`", "\\`", "|", "\\|", "_", "\\_").Replace(text)
+}
+
+// link 匿名链接，不会因为链接文字重复而报错
+func (d rstDialect) link(text string, href string) string {
+	text = strings.NewReplacer("<", "\\<", "`", "\\`").Replace(text)
+	if text == "" || text == href {
+		return "`<" + href + ">`__"
+	}
+	return "`" + text + " <" + href + ">`__"
+}
+
+func (rstDialect) image(alt string, src string) string {
+	directive := ".. image:: " + src
+	if alt != "" {
+		directive += "\n   :alt: " + alt
+	}
+	return directive
+}
+
+// inlineImage reST 没有行内图片的写法，用链接代替
+func (d rstDialect) inlineImage(alt string, src string) string {
+	return d.link(firstNonEmpty(alt, "图片"), src)
+}
+
+func (rstDialect) codeBlock(rows []string, lang string) string {
+	head := "::"
+	if lang != "" {
+		head = ".. code-block:: " + lang
+	}
+	var lines []string
+	for _, row := range rows {
+		if strings.TrimSpace(row) == "" {
+			lines = append(lines, "")
+		} else {
+			lines = append(lines, "   "+row)
+		}
+	}
+	return head + "\n\n" + strings.Join(lines, "\n")
+}
+
+// math 行内公式用 :math: 角色，独占一段的公式用 math 指令
+func (rstDialect) math(tex string, display bool) string {
+	if display {
+		return ".. math::\n\n" + indentLines(tex, "   ", "   ")
+	}
+	return ":math:`" + strings.ReplaceAll(tex, "`", "\\`") + "`"
+}
+
+// quote 缩进的块即为引用，嵌套时缩进叠加；前面的空注释 .. 让它不会被当成上一个列表项的后续内容
+func (rstDialect) quote(body string, depth int) string {
+	return "..\n\n" + indentLines(body, "    ", "    ")
+}
+
+// list 嵌套列表与列表项文字之间要有空行
+func (rstDialect) list(items []markupItem, ordered bool, depth int) string {
+	var texts []string
+	sep := "\n"
+	for _, item := range items {
+		if len(item.blocks) > 1 {
+			// 列表项里有多个块时，下一项之前也要空行
+			sep = "\n\n"
+		}
+		marker := "- "
+		if ordered {
+			marker = firstNonEmpty(item.number, "1") + ". "
+		}
+		var blocks []string
+		for _, block := range item.blocks {
+			blocks = append(blocks, block.text)
+		}
+		texts = append(texts, strings.TrimRight(indentLines(strings.Join(blocks, "\n\n"), marker, strings.Repeat(" ", len(marker))), " "))
+	}
+	return strings.Join(texts, sep)
+}
+
+// table 用 list-table，不需要按中文宽度对齐；不支持合并单元格，被占住的位置留空
+func (rstDialect) table(rows [][]markupCell, hasHeader bool, cols int) string {
+	lines := []string{".. list-table::"}
+	if hasHeader {
+		lines = append(lines, "   :header-rows: 1")
+	}
+	lines = append(lines, "")
+	for _, row := range rows {
+		for i, cell := range row {
+			marker := "     - "
+			if i == 0 {
+				marker = "   * - "
+			}
+			lines = append(lines, strings.TrimRight(marker+cell.text, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (rstDialect) hr() string {
+	return "----------"
+}
@@ -0,0 +1,202 @@
+package format
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+var blankReg = regexp.MustCompile(`\s+`)
+
+// formatTable 能用GFM管道表格表示时输出管道表格；
+// 有跨行/跨列单元格，或单元格里有列表、代码块等块级内容时，输出去掉样式的html表格。
+// 方言不支持管道表格时一律输出html表格，只支持管道表格时把合并的单元格拆开
+func formatTable(piece parse.Piece, opts *Options) (string, map[string][]byte) {
+	if native, ok := piece.Val.(string); ok {
+		return native, nil
+	}
+	table := piece.Val.(parse.Table)
+	syntax := opts.profile().table
+	if syntax == TABLE_PIPE && table.HasSpan() {
+		table = splitSpans(table)
+	}
+	if syntax != TABLE_HTML && !table.HasSpan() {
+		if mdStr, saveImageBytes, ok := formatGFMTable(table, opts); ok {
+			return mdStr, saveImageBytes
+		}
+	}
+	htmlStr, saveImageBytes := formatHTMLTable(table)
+	return "\n" + htmlStr + "\n\n", saveImageBytes
+}
+
+func formatGFMTable(table parse.Table, opts *Options) (string, map[string][]byte, bool) {
+	saveImageBytes := make(map[string][]byte)
+	cols := table.ColCount()
+	var lines []string
+	rows := table.Rows
+	var header []string
+	if table.HasHeader {
+		header = make([]string, 0, cols)
+		for _, cell := range rows[0] {
+			cellStr, images, ok := formatGFMCell(cell.Content, opts)
+			if !ok {
+				return "", nil, false
+			}
+			mergeMap(saveImageBytes, images)
+			header = append(header, cellStr)
+		}
+		rows = rows[1:]
+	}
+	lines = append(lines, formatGFMRow(header, cols))
+
+	// 对齐方式取自第一行
+	var delimiter []string
+	for i := 0; i < cols; i++ {
+		var align string
+		if len(table.Rows[0]) > i {
+			align = table.Rows[0][i].Align
+		}
+		switch align {
+		case "left":
+			delimiter = append(delimiter, ":---")
+		case "center":
+			delimiter = append(delimiter, ":---:")
+		case "right":
+			delimiter = append(delimiter, "---:")
+		default:
+			delimiter = append(delimiter, "---")
+		}
+	}
+	lines = append(lines, formatGFMRow(delimiter, cols))
+
+	for _, row := range rows {
+		var cells []string
+		for _, cell := range row {
+			cellStr, images, ok := formatGFMCell(cell.Content, opts)
+			if !ok {
+				return "", nil, false
+			}
+			mergeMap(saveImageBytes, images)
+			cells = append(cells, cellStr)
+		}
+		lines = append(lines, formatGFMRow(cells, cols))
+	}
+	return "\n" + strings.Join(lines, "\n") + "\n\n", saveImageBytes, true
+}
+
+func formatGFMRow(cells []string, cols int) string {
+	for len(cells) < cols {
+		cells = append(cells, "")
+	}
+	return "| " + strings.Join(cells, " | ") + " |"
+}
+
+// formatGFMCell 单元格只能放行内内容，遇到块级内容时返回 false
+func formatGFMCell(pieces []parse.Piece, opts *Options) (string, map[string][]byte, bool) {
+	var cellStr string
+	saveImageBytes := make(map[string][]byte)
+	for _, piece := range pieces {
+		switch piece.Type {
+		case parse.NORMAL_TEXT:
+			cellStr += blankReg.ReplaceAllString(piece.Val.(string), " ")
+		case parse.BOLD_TEXT, parse.ITALIC_TEXT, parse.BOLD_ITALIC_TEXT,
+			parse.STRIKETHROUGH_TEXT, parse.UNDERLINE_TEXT, parse.SUP_TEXT, parse.SUB_TEXT,
+			parse.HIGHLIGHT_TEXT, parse.CODE_INLINE:
+			piece.Val = blankReg.ReplaceAllString(piece.Val.(string), " ")
+			cellStr += formatInlineText(piece, opts)
+		case parse.LINK:
+			cellStr += "[" + piece.Val.(string) + "](" + piece.Attrs["href"] + ")"
+		case parse.MATH:
+			// 单元格里的公式都按行内公式输出
+			cellStr += "$" + blankReg.ReplaceAllString(mathTeX(piece), " ") + "$"
+		case parse.IMAGE:
+			if piece.Val == nil {
+				cellStr += "![" + piece.Attrs["alt"] + "](" + piece.Attrs["src"] + ")"
+			} else {
+				hashName := imageFileName(piece)
+				saveImageBytes[hashName] = piece.Val.([]byte)
+				cellStr += opts.profile().formatLocalImage(piece.Attrs["alt"], hashName)
+			}
+		case parse.IMAGE_BASE64:
+			cellStr += formatImageBase64Inline(piece)
+		case parse.BR:
+			cellStr += "<br>"
+		case parse.NULL:
+		default:
+			return "", nil, false
+		}
+	}
+	cellStr = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(cellStr), "<br>"))
+	return strings.ReplaceAll(cellStr, "|", "\\|"), saveImageBytes, true
+}
+
+// splitSpans 把跨行/跨列的单元格拆开，内容留在左上角，其余位置补空单元格
+func splitSpans(table parse.Table) parse.Table {
+	covered := make(map[[2]int]bool)
+	var rows []parse.TableRow
+	for r, row := range table.Rows {
+		var cells parse.TableRow
+		c := 0
+		for _, cell := range row {
+			for ; covered[[2]int{r, c}]; c++ {
+				cells = append(cells, parse.TableCell{IsHeader: cell.IsHeader, ColSpan: 1, RowSpan: 1})
+			}
+			colSpan, rowSpan := cell.ColSpan, cell.RowSpan
+			if colSpan < 1 {
+				colSpan = 1
+			}
+			if rowSpan < 1 {
+				rowSpan = 1
+			}
+			for dr := 0; dr < rowSpan; dr++ {
+				for dc := 0; dc < colSpan; dc++ {
+					if dr > 0 || dc > 0 {
+						covered[[2]int{r + dr, c + dc}] = true
+					}
+				}
+			}
+			split := cell
+			split.ColSpan, split.RowSpan = 1, 1
+			cells = append(cells, split)
+			c++
+			for ; covered[[2]int{r, c}]; c++ {
+				cells = append(cells, parse.TableCell{IsHeader: cell.IsHeader, ColSpan: 1, RowSpan: 1})
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return parse.Table{Rows: rows, HasHeader: table.HasHeader}
+}
+
+// formatHTMLTable 输出不带样式的html表格，保留跨行/跨列
+func formatHTMLTable(table parse.Table) (string, map[string][]byte) {
+	saveImageBytes := make(map[string][]byte)
+	htmlStr := "<table>\n"
+	for _, row := range table.Rows {
+		htmlStr += "<tr>"
+		for _, cell := range row {
+			tag := "td"
+			if cell.IsHeader {
+				tag = "th"
+			}
+			htmlStr += "<" + tag
+			if cell.ColSpan > 1 {
+				htmlStr += " colspan=\"" + strconv.Itoa(cell.ColSpan) + "\""
+			}
+			if cell.RowSpan > 1 {
+				htmlStr += " rowspan=\"" + strconv.Itoa(cell.RowSpan) + "\""
+			}
+			if cell.Align != "" {
+				htmlStr += " align=\"" + cell.Align + "\""
+			}
+			cellHTML, images := formatHTMLPieces(cell.Content)
+			mergeMap(saveImageBytes, images)
+			htmlStr += ">" + strings.TrimSpace(cellHTML) + "</" + tag + ">"
+		}
+		htmlStr += "</tr>\n"
+	}
+	htmlStr += "</table>"
+	return htmlStr, saveImageBytes
+}
@@ -0,0 +1,50 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestFormatTable(t *testing.T) {
+	bold := parse.Piece{Type: parse.BOLD_TEXT, Val: "粗"}
+	list := parse.Piece{Type: parse.U_LIST, Val: []parse.Piece{text("项")}}
+	tests := []struct {
+		name  string
+		table parse.Table
+		want  string
+	}{
+		{
+			"pipe table",
+			parse.Table{HasHeader: true, Rows: []parse.TableRow{
+				{cell("名称"), {Content: []parse.Piece{text("数量")}, ColSpan: 1, RowSpan: 1, Align: "right"}},
+				{cell("a|b"), {Content: []parse.Piece{bold, {Type: parse.BR}, text("3")}, ColSpan: 1, RowSpan: 1}},
+			}},
+			"\n| 名称 | 数量 |\n| --- | ---: |\n| a\\|b | **粗**<br>3 |\n\n",
+		},
+		{
+			"no header",
+			parse.Table{Rows: []parse.TableRow{{cell("a"), cell("b")}}},
+			"\n|  |  |\n| --- | --- |\n| a | b |\n\n",
+		},
+		{
+			"span falls back to html",
+			parse.Table{Rows: []parse.TableRow{
+				{{Content: []parse.Piece{text("合并")}, ColSpan: 2, RowSpan: 1}},
+				{cell("a"), cell("b")},
+			}},
+			"\n<table>\n<tr><td colspan=\"2\">合并</td></tr>\n<tr><td>a</td><td>b</td></tr>\n</table>\n\n",
+		},
+		{
+			"block content falls back to html",
+			parse.Table{Rows: []parse.TableRow{{{Content: []parse.Piece{list}, ColSpan: 1, RowSpan: 1}}}},
+			"\n<table>\n<tr><td><ul><li>项</li></ul></td></tr>\n</table>\n\n",
+		},
+	}
+	for _, tt := range tests {
+		got, _ := formatTable(parse.Piece{Type: parse.TABLE, Val: tt.table}, &Options{})
+		if got != tt.want {
+			t.Errorf("%s:\n got: %q\nwant: %q", tt.name, got, tt.want)
+		}
+	}
+}
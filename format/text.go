@@ -0,0 +1,123 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// textRenderer 输出纯文本，图片等非文字内容都会被丢弃
+type textRenderer struct{}
+
+func (textRenderer) Render(article parse.Article, opts Options) ([]byte, map[string][]byte, error) {
+	var textContent strings.Builder
+
+	// 添加标题
+	title, _ := article.Title.Val.(string)
+	textContent.WriteString(title)
+	textContent.WriteString("\n\n")
+
+	// 添加内容正文（仅文本）
+	textContent.WriteString(formatText(article.Content))
+	links := sourceLinks(article.Metadata)
+	if len(links) > 0 {
+		// 正文可能没有以换行结尾（如链接），来源另起一段
+		textContent.WriteString("\n\n")
+	}
+	for _, link := range links {
+		if link.url != "" && link.url != link.text {
+			textContent.WriteString(link.label + "：" + link.text + " " + link.url + "\n")
+		} else {
+			textContent.WriteString(link.label + "：" + link.text + "\n")
+		}
+	}
+	return []byte(textContent.String()), nil, nil
+}
+
+func (textRenderer) Ext() string {
+	return "txt"
+}
+
+// formatText 从Piece列表中提取纯文本
+func formatText(pieces []parse.Piece) string {
+	var text strings.Builder
+
+	for _, piece := range pieces {
+		switch piece.Type {
+		case parse.HEADER:
+			// 添加标题文本
+			text.WriteString(piece.Val.(string))
+			text.WriteString("\n\n")
+		case parse.NORMAL_TEXT, parse.BOLD_TEXT, parse.ITALIC_TEXT, parse.BOLD_ITALIC_TEXT,
+			parse.STRIKETHROUGH_TEXT, parse.UNDERLINE_TEXT, parse.HIGHLIGHT_TEXT, parse.SUP_TEXT, parse.SUB_TEXT, parse.CODE_INLINE:
+			// 添加普通文本
+			if str, ok := piece.Val.(string); ok {
+				text.WriteString(str)
+			}
+		case parse.MATH:
+			// 公式输出 TeX 源码，独占一行的公式单独成行
+			if isDisplayMath(piece) {
+				text.WriteString(mathTeX(piece))
+				text.WriteString("\n")
+			} else {
+				text.WriteString(mathTeX(piece))
+			}
+		case parse.LINK:
+			// 只添加链接的文本部分
+			if str, ok := piece.Val.(string); ok {
+				text.WriteString(str)
+			}
+		case parse.BLOCK_QUOTES:
+			// 递归处理引用块
+			if subPieces, ok := piece.Val.([]parse.Piece); ok {
+				text.WriteString(formatText(subPieces))
+				text.WriteString("\n")
+			}
+		case parse.O_LIST, parse.U_LIST:
+			// 递归处理列表，每个列表项（包括嵌套的子列表项）各占一行
+			if subPieces, ok := piece.Val.([]parse.Piece); ok {
+				if text.Len() > 0 && !strings.HasSuffix(text.String(), "\n") {
+					text.WriteString("\n")
+				}
+				item := formatText(subPieces)
+				text.WriteString(item)
+				if !strings.HasSuffix(item, "\n") {
+					text.WriteString("\n")
+				}
+			}
+		case parse.TABLE:
+			// 表格每行一行，单元格之间用制表符分隔
+			if table, ok := piece.Val.(parse.Table); ok {
+				for _, row := range table.Rows {
+					var cells []string
+					for _, cell := range row {
+						cells = append(cells, strings.TrimSpace(formatText(cell.Content)))
+					}
+					text.WriteString(strings.Join(cells, "\t"))
+					text.WriteString("\n")
+				}
+				text.WriteString("\n")
+			}
+		case parse.CODE_BLOCK:
+			// 添加代码块内容
+			if codeRows, ok := piece.Val.([]string); ok {
+				for _, row := range codeRows {
+					text.WriteString(row)
+					text.WriteString("\n")
+				}
+				text.WriteString("\n")
+			}
+		case parse.VIDEO, parse.AUDIO, parse.EMBED_CARD:
+			// 视频、音频、卡片只保留标题
+			if title := piece.Attrs["title"]; title != "" {
+				text.WriteString(title)
+				text.WriteString("\n")
+			}
+		case parse.BR:
+			// 处理换行
+			text.WriteString("\n")
+		}
+	}
+
+	return text.String()
+}
@@ -0,0 +1,124 @@
+package format
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func mergeMap(m1 map[string][]byte, m2 map[string][]byte) {
+	for k, v := range m2 {
+		m1[k] = v
+	}
+}
+
+func md5Hex(content []byte) string {
+	hash := md5.New()
+	hash.Write(content)
+	md5Bytes := hash.Sum(nil)
+	return hex.EncodeToString(md5Bytes)
+}
+
+// 从图片src中解析出图片的扩展名
+func parseImageExtFromSrc(src string) string {
+	reg := regexp.MustCompile(`(wx_fmt=)([a-zA-Z]+)(&?)`)
+	matches := reg.FindStringSubmatch(src)
+	if len(matches) < 3 {
+		return ""
+	}
+	return matches[2]
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, val := range vals {
+		if val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sourceLink 文末的来源链接：转载、分享的原文或“阅读原文”
+type sourceLink struct {
+	label string // 转载自、阅读原文
+	text  string
+	url   string // 可能为空（只知道原文的公众号）
+}
+
+// sourceLinks 文章的转载来源和“阅读原文”链接，都没有时返回 nil
+func sourceLinks(md parse.Metadata) []sourceLink {
+	var links []sourceLink
+	if md.ShareURL != "" || md.ShareAccount != "" {
+		links = append(links, sourceLink{"转载自", firstNonEmpty(md.ShareAccount, md.ShareURL), md.ShareURL})
+	}
+	if md.SourceURL != "" {
+		links = append(links, sourceLink{"阅读原文", md.SourceURL, md.SourceURL})
+	}
+	return links
+}
+
+// isDisplayMath 是否为独占一行的公式
+func isDisplayMath(piece parse.Piece) bool {
+	return piece.Type == parse.MATH && piece.Attrs["display"] == "true"
+}
+
+// mathTeX 公式的 TeX 源码。行内公式合并成一行；独占一行的公式去掉空行，空行会打断 Markdown 等的段落
+func mathTeX(piece parse.Piece) string {
+	tex := strings.TrimSpace(piece.Val.(string))
+	if !isDisplayMath(piece) {
+		return blankReg.ReplaceAllString(tex, " ")
+	}
+	var lines []string
+	for _, line := range strings.Split(tex, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hasMath 正文中（包括引用、列表和表格里）是否有公式
+func hasMath(pieces []parse.Piece) bool {
+	for _, piece := range pieces {
+		switch val := piece.Val.(type) {
+		case []parse.Piece:
+			if hasMath(val) {
+				return true
+			}
+		case parse.Table:
+			for _, row := range val.Rows {
+				for _, cell := range row {
+					if hasMath(cell.Content) {
+						return true
+					}
+				}
+			}
+		}
+		if piece.Type == parse.MATH {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fengxxc/wechatmp2markdown/format"
 	"github.com/fengxxc/wechatmp2markdown/parse"
@@ -46,7 +49,7 @@ func main() {
 	if args[1] == "batch" {
 		if len(args) <= 2 {
 			fmt.Println("错误: 缺少目录路径参数")
-			fmt.Println("用法: wechatmp2markdown batch [公众号目录路径] [--image=选项]")
+			fmt.Println("用法: wechatmp2markdown batch [公众号目录路径] [--image=选项] [--format=格式]")
 			return
 		}
 
@@ -55,28 +58,19 @@ func main() {
 		dirPath = strings.ReplaceAll(dirPath, "\"", "")
 
 		// 获取图片处理方式
-		imageArgValue := "base64"
-		if len(args) > 3 && args[3] != "" {
-			if strings.HasPrefix(args[3], "--image=") {
-				imageArgValue = args[3][len("--image="):]
-			} else if strings.HasPrefix(args[3], "-i") {
-				imageArgVal := args[3][len("-i"):]
-				switch imageArgVal {
-				case "u":
-					imageArgValue = "url"
-				case "s":
-					imageArgValue = "save"
-				case "b":
-					fallthrough
-				default:
-					imageArgValue = "base64"
-				}
-			}
+		imagePolicy := parse.ImageArgValue2ImagePolicy(imageArgValue(args[3:], "base64"))
+		parseOpts, err := parseOptionArgs(args[3:], imagePolicy)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
+		}
+		renderer, err := rendererArg(args[3:], format.DefaultRendererName)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
 		}
 
-		imagePolicy := parse.ImageArgValue2ImagePolicy(imageArgValue)
-
-		count, err := util.BatchConvertHTMLFiles(dirPath, imagePolicy)
+		count, err := util.BatchConvertHTMLFiles(dirPath, parseOpts, renderer, formatOptionArgs(args[3:]))
 		if err != nil {
 			fmt.Printf("批量转换HTML文件失败: %v\n", err)
 			return
@@ -98,7 +92,20 @@ func main() {
 		dirPath := args[2]
 		dirPath = strings.ReplaceAll(dirPath, "\"", "")
 
-		count, err := util.BatchConvertHTMLFilesToTxt(dirPath)
+		// 与 batch 相同，只是默认输出纯文本、图片只保留链接
+		imagePolicy := parse.ImageArgValue2ImagePolicy(imageArgValue(args[3:], "url"))
+		parseOpts, err := parseOptionArgs(args[3:], imagePolicy)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
+		}
+		renderer, err := rendererArg(args[3:], "text")
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
+		}
+
+		count, err := util.BatchConvertHTMLFiles(dirPath, parseOpts, renderer, formatOptionArgs(args[3:]))
 		if err != nil {
 			fmt.Printf("批量转换HTML文件到TXT失败: %v\n", err)
 			return
@@ -108,6 +115,38 @@ func main() {
 		return
 	}
 
+	// 把公众号目录合成电子书
+	if args[1] == "epub" {
+		if len(args) <= 2 {
+			fmt.Println("错误: 缺少目录路径参数")
+			fmt.Println("用法: wechatmp2markdown epub [公众号目录路径] [输出目录] [--image=选项]")
+			return
+		}
+
+		// 处理路径中可能包含的引号
+		dirPath := strings.ReplaceAll(args[2], "\"", "")
+		outputPath := ""
+		if len(args) > 3 && !strings.HasPrefix(args[3], "-") {
+			outputPath = strings.ReplaceAll(args[3], "\"", "")
+		}
+
+		imagePolicy := parse.ImageArgValue2ImagePolicy(imageArgValue(args[3:], "base64"))
+		parseOpts, err := parseOptionArgs(args[3:], imagePolicy)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
+		}
+
+		epubFiles, err := util.BatchConvertHTMLFilesToEpub(dirPath, outputPath, parseOpts, formatOptionArgs(args[3:]))
+		if err != nil {
+			fmt.Printf("生成电子书失败: %v\n", err)
+			return
+		}
+
+		fmt.Printf("成功生成 %d 本电子书\n", len(epubFiles))
+		return
+	}
+
 	if len(args) <= 2 {
 		fmt.Println("错误: 参数不足")
 		printUsage()
@@ -127,7 +166,15 @@ func main() {
 		if port == "" {
 			port = "8964"
 		}
-		server.Start(":" + port)
+		// 图片下载的选项对所有请求生效，图片处理方式由请求参数决定
+		parseOpts, err := parseOptionArgs(args[3:], parse.IMAGE_POLICY_BASE64)
+		if err != nil {
+			fmt.Println("错误:", err)
+			return
+		}
+		if err := server.Start(":"+port, parseOpts); err != nil {
+			fmt.Printf("错误: %v\n", err)
+		}
 		return
 	}
 
@@ -158,12 +205,25 @@ func main() {
 
 		// 设置输出路径，如果未提供则使用当前目录
 		outputPath := "./"
-		if len(args) > 3 {
+		if len(args) > 3 && !strings.HasPrefix(args[3], "-") {
 			outputPath = args[3]
 			outputPath = strings.ReplaceAll(outputPath, "\"", "")
 		}
 
-		txtFilePath, err := util.ConvertHTMLFileToTxt(htmlFilePath, outputPath)
+		// 默认输出纯文本、图片只保留链接
+		imagePolicy := parse.ImageArgValue2ImagePolicy(imageArgValue(args[3:], "url"))
+		parseOpts, err := parseOptionArgs(args[3:], imagePolicy)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
+		}
+		renderer, err := rendererArg(args[3:], "text")
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
+		}
+
+		txtFilePath, err := util.ConvertHTMLFile(htmlFilePath, outputPath, parseOpts, renderer, formatOptionArgs(args[3:]))
 		if err != nil {
 			fmt.Printf("转换HTML文件到TXT失败: %v\n", err)
 			return
@@ -177,31 +237,23 @@ func main() {
 	// --image=url 		-iu 只保留图片链接
 	// --image=save 	-is 保存图片，最终输出到文件夹
 	// --save=zip -sz 		最终打包输出到zip
-	imageArgValue := "base64"
-	imageArgIdx := 3
-	if isLocalFile {
-		imageArgIdx = 4 // 如果是本地文件模式，参数位置后移
+	optionArgs := args[3:]
+	if isLocalFile && len(args) > 4 {
+		optionArgs = args[4:] // 如果是本地文件模式，参数位置后移
+	} else if isLocalFile {
+		optionArgs = nil
 	}
-
-	if len(args) > imageArgIdx && args[imageArgIdx] != "" {
-		if strings.HasPrefix(args[imageArgIdx], "--image=") {
-			imageArgValue = args[imageArgIdx][len("--image="):]
-		} else if strings.HasPrefix(args[imageArgIdx], "-i") {
-			imageArgVal := args[imageArgIdx][len("-i"):]
-			switch imageArgVal {
-			case "u":
-				imageArgValue = "url"
-			case "s":
-				imageArgValue = "save"
-			case "b":
-				fallthrough
-			default:
-				imageArgValue = "base64"
-			}
-		}
+	var imagePolicy parse.ImagePolicy = parse.ImageArgValue2ImagePolicy(imageArgValue(optionArgs, "base64"))
+	parseOpts, err := parseOptionArgs(optionArgs, imagePolicy)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return
+	}
+	renderer, err := rendererArg(optionArgs, format.DefaultRendererName)
+	if err != nil {
+		fmt.Printf("错误: %v\n", err)
+		return
 	}
-
-	var imagePolicy parse.ImagePolicy = parse.ImageArgValue2ImagePolicy(imageArgValue)
 
 	var articleStruct parse.Article
 
@@ -209,16 +261,284 @@ func main() {
 		// 从本地HTML文件解析
 		htmlFilePath := args1
 		fmt.Printf("HTML file: %s, output: %s\n", htmlFilePath, args2)
-		articleStruct = parse.ParseFromHTMLFile(htmlFilePath, imagePolicy)
+		articleStruct, err = parse.ParseFromHTMLFileWithOptions(htmlFilePath, parseOpts)
 	} else {
 		// cli pattern - 从URL解析
 		url := args1
 		filename := args2
 		fmt.Printf("url: %s, filename: %s\n", url, filename)
-		articleStruct = parse.ParseFromURL(url, imagePolicy)
+		articleStruct, err = parse.ParseFromURLWithOptions(url, parseOpts)
+	}
+	if err != nil {
+		fmt.Printf("解析文章失败: %v\n", err)
+		if errors.Is(err, parse.ErrVerify) {
+			fmt.Println("微信要求验证，请稍后重试，或用 --cookie 带上浏览器中的 cookie")
+		}
+		return
+	}
+	if articleStruct.FollowErr != nil {
+		fmt.Printf("原文转换失败，改为转换转载的文章: %v\n", articleStruct.FollowErr)
 	}
 
-	format.FormatAndSave(articleStruct, args2)
+	if err := format.RenderAndSave(articleStruct, args2, renderer, formatOptionArgs(optionArgs)); err != nil {
+		fmt.Printf("保存文章失败: %v\n", err)
+	}
+}
+
+// imageArgValue 在 args 中查找图片处理方式（--image=xxx 或 -iu/-is/-ib），没有时返回 def
+func imageArgValue(args []string, def string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--image=") {
+			return arg[len("--image="):]
+		} else if strings.HasPrefix(arg, "-i") {
+			switch arg[len("-i"):] {
+			case "u":
+				return "url"
+			case "s":
+				return "save"
+			default:
+				return "base64"
+			}
+		}
+	}
+	return def
+}
+
+// parseOptionArgs 由命令行参数得到解析选项
+func parseOptionArgs(args []string, imagePolicy parse.ImagePolicy) (parse.Options, error) {
+	parseOpts := parse.Options{ImagePolicy: imagePolicy}
+	// --header-threshold=1.15 由内联样式推断小标题的字号比例阈值，越小越激进，负数为不推断
+	if val, ok := optionArgValue(args, "--header-threshold="); ok {
+		threshold, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return parseOpts, fmt.Errorf("无效的 --header-threshold 值 '%s'", val)
+		}
+		parseOpts.HeaderThreshold = threshold
+	}
+	// --image-workers=8 同时下载的图片数
+	if val, ok := optionArgValue(args, "--image-workers="); ok {
+		workers, err := strconv.Atoi(val)
+		if err != nil || workers <= 0 {
+			return parseOpts, fmt.Errorf("无效的 --image-workers 值 '%s'", val)
+		}
+		parseOpts.ImageWorkers = workers
+	}
+	// --image-retries=2 图片下载失败后的重试次数，0为不重试
+	if val, ok := optionArgValue(args, "--image-retries="); ok {
+		retries, err := strconv.Atoi(val)
+		if err != nil || retries < 0 {
+			return parseOpts, fmt.Errorf("无效的 --image-retries 值 '%s'", val)
+		}
+		if retries == 0 {
+			retries = -1
+		}
+		parseOpts.ImageRetries = retries
+	}
+	// --image-timeout=30s 单张图片每次下载的超时
+	if val, ok := optionArgValue(args, "--image-timeout="); ok {
+		timeout, err := time.ParseDuration(val)
+		if err != nil || timeout <= 0 {
+			return parseOpts, fmt.Errorf("无效的 --image-timeout 值 '%s'", val)
+		}
+		parseOpts.ImageTimeout = timeout
+	}
+	// --image-cache[=目录] 下载的图片缓存到磁盘，不写目录时使用用户缓存目录；--image-cache-size=1GB 缓存的大小上限
+	cacheDir, useCache := optionArgValue(args, "--image-cache=")
+	if useCache || hasArg(args, "--image-cache") {
+		var cacheSize int64
+		if val, ok := optionArgValue(args, "--image-cache-size="); ok {
+			size, err := parseByteSize(val)
+			if err != nil || size <= 0 {
+				return parseOpts, fmt.Errorf("无效的 --image-cache-size 值 '%s'", val)
+			}
+			cacheSize = size
+		}
+		if cacheDir == "" {
+			dir, err := parse.DefaultImageCacheDir()
+			if err != nil {
+				return parseOpts, fmt.Errorf("找不到默认的图片缓存目录，请用 --image-cache=目录 指定: %v", err)
+			}
+			cacheDir = dir
+		}
+		cache, err := parse.NewImageCache(cacheDir, cacheSize)
+		if err != nil {
+			return parseOpts, fmt.Errorf("打开图片缓存 '%s' 失败: %v", cacheDir, err)
+		}
+		parseOpts.ImageCache = cache
+	}
+	// --follow-share 转载、分享的文章改为转换原文
+	parseOpts.FollowShare = hasArg(args, "--follow-share")
+	client, err := clientOptionArgs(args)
+	if err != nil {
+		return parseOpts, err
+	}
+	if client != nil {
+		parseOpts.Fetcher = client
+	}
+	// --record=目录 把文章页面和图片的响应录制到目录；--replay=目录 从目录回放，不访问网络
+	if dir, ok := optionArgValue(args, "--record="); ok {
+		recorder, err := parse.NewRecorder(dir, parseOpts.Fetcher)
+		if err != nil {
+			return parseOpts, fmt.Errorf("无法录制到 '%s': %v", dir, err)
+		}
+		parseOpts.Fetcher = recorder
+	} else if dir, ok := optionArgValue(args, "--replay="); ok {
+		replayer, err := parse.NewReplayer(dir)
+		if err != nil {
+			return parseOpts, fmt.Errorf("无法从 '%s' 回放: %v", dir, err)
+		}
+		parseOpts.Fetcher = replayer
+	}
+	return parseOpts, nil
+}
+
+// clientOptionArgs 由命令行参数得到请求页面和下载图片的 HTTP 设置，都没有指定时返回 nil 使用默认设置
+func clientOptionArgs(args []string) (*parse.Client, error) {
+	clientOpts := parse.ClientOptions{}
+	set := false
+	// --user-agent=... 请求的 User-Agent
+	if val, ok := optionArgValue(args, "--user-agent="); ok {
+		clientOpts.UserAgent = val
+		set = true
+	}
+	// --referer=https://mp.weixin.qq.com/ 下载图片时的 Referer，none 为不带 Referer
+	if val, ok := optionArgValue(args, "--referer="); ok {
+		clientOpts.Referer = val
+		set = true
+	}
+	// --header="Name: value" 额外的请求头，可以写多个
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--header=") {
+			continue
+		}
+		name, value, ok := strings.Cut(arg[len("--header="):], ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("无效的 --header 值 '%s'，格式为 \"Name: value\"", arg[len("--header="):])
+		}
+		if clientOpts.Headers == nil {
+			clientOpts.Headers = make(map[string]string)
+		}
+		clientOpts.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		set = true
+	}
+	// --cookie="name=value; name2=value2" 请求公众号页面时带上的 cookie
+	if val, ok := optionArgValue(args, "--cookie="); ok {
+		clientOpts.Cookies = val
+		set = true
+	}
+	// --proxy=socks5://127.0.0.1:1080 代理
+	if val, ok := optionArgValue(args, "--proxy="); ok {
+		clientOpts.Proxy = val
+		set = true
+	}
+	// --insecure 不校验 TLS 证书；--ca-cert=ca.pem 额外信任的 CA 证书
+	if hasArg(args, "--insecure") {
+		clientOpts.Insecure = true
+		set = true
+	}
+	if val, ok := optionArgValue(args, "--ca-cert="); ok {
+		clientOpts.CACertFile = val
+		set = true
+	}
+	// --timeout=30s 请求文章页面的超时
+	if val, ok := optionArgValue(args, "--timeout="); ok {
+		timeout, err := time.ParseDuration(val)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("无效的 --timeout 值 '%s'", val)
+		}
+		clientOpts.Timeout = timeout
+		set = true
+	}
+	// --placeholder-hash=sha256,... 已知的防盗链占位图
+	if val, ok := optionArgValue(args, "--placeholder-hash="); ok {
+		for _, hash := range strings.Split(val, ",") {
+			if hash = strings.TrimSpace(hash); hash != "" {
+				clientOpts.PlaceholderHashes = append(clientOpts.PlaceholderHashes, hash)
+			}
+		}
+		set = true
+	}
+	if !set {
+		return nil, nil
+	}
+	client, err := parse.NewClient(clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("无效的网络设置: %v", err)
+	}
+	return client, nil
+}
+
+// hasArg 是否有不带值的选项，如 --insecure
+func hasArg(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseByteSize 解析 1048576、512KB、100MB、2GB 这样的大小
+func parseByteSize(val string) (int64, error) {
+	num := strings.ToUpper(strings.TrimSpace(val))
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(num, u.suffix) {
+			num = strings.TrimSpace(strings.TrimSuffix(num, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+	size, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(size * float64(unit)), nil
+}
+
+// formatOptionArgs 由命令行参数得到输出选项
+func formatOptionArgs(args []string) format.Options {
+	// --media=link|html5 视频、音频输出为带封面的链接（默认）或html5标签
+	formatOpts := format.Options{}
+	if val, ok := optionArgValue(args, "--media="); ok {
+		formatOpts.MediaStyle = format.MediaArgValue2MediaStyle(val)
+	}
+	// --flavor=commonmark|gfm|obsidian|typora|hexo|logseq Markdown 方言
+	if val, ok := optionArgValue(args, "--flavor="); ok {
+		formatOpts.Flavor = format.FlavorArgValue2Flavor(val)
+	}
+	// --front-matter=yaml|toml|properties|none 输出 front matter，未指定时按方言的习惯；--front-matter-fields=title,date:publishDate 指定字段及字段名
+	if val, ok := optionArgValue(args, "--front-matter="); ok {
+		formatOpts.FrontMatter = format.FrontMatterArgValue2FrontMatterStyle(val)
+	} else {
+		formatOpts.FrontMatter = formatOpts.Flavor.DefaultFrontMatter()
+	}
+	if val, ok := optionArgValue(args, "--front-matter-fields="); ok {
+		formatOpts.FrontMatterFields = format.ParseFrontMatterFields(val)
+	}
+	return formatOpts
+}
+
+// rendererArg --format=markdown|text|html 输出格式，没有时使用 def
+func rendererArg(args []string, def string) (format.Renderer, error) {
+	name, ok := optionArgValue(args, "--format=")
+	if !ok {
+		name = def
+	}
+	return format.GetRenderer(name)
+}
+
+// optionArgValue 在 args 中查找以 prefix 开头的参数（如 --xxx=），返回等号后的值
+func optionArgValue(args []string, prefix string) (string, bool) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, prefix) {
+			return arg[len(prefix):], true
+		}
+	}
+	return "", false
 }
 
 // 打印使用说明
@@ -226,19 +546,19 @@ func printUsage() {
 	fmt.Println("wechatmp2markdown - 微信公众号文章转Markdown工具")
 	fmt.Println("\n用法:")
 	fmt.Println("  1. 从URL转换:")
-	fmt.Println("     wechatmp2markdown [url] [输出路径] [--image=选项]")
+	fmt.Println("     wechatmp2markdown [url] [输出路径] [--image=选项] [--format=格式]")
 	fmt.Println("     例如: wechatmp2markdown https://mp.weixin.qq.com/s/xxx ./output --image=save")
 	fmt.Println("\n  2. 从本地HTML文件转换:")
-	fmt.Println("     wechatmp2markdown file [HTML文件路径] [输出路径] [--image=选项]")
+	fmt.Println("     wechatmp2markdown file [HTML文件路径] [输出路径] [--image=选项] [--format=格式]")
 	fmt.Println("     例如: wechatmp2markdown file ./article.html ./output --image=save")
 	fmt.Println("\n  3. 启动Web服务:")
-	fmt.Println("     wechatmp2markdown server [端口号]")
+	fmt.Println("     wechatmp2markdown server [端口号] [--image-workers=8]")
 	fmt.Println("     例如: wechatmp2markdown server 8964")
 	fmt.Println("\n  4. 批量重命名目录:")
 	fmt.Println("     wechatmp2markdown rename [公众号目录路径]")
 	fmt.Println("     例如: wechatmp2markdown rename D:\\WechatDownload\\浙江宣传")
 	fmt.Println("\n  5. 批量转换HTML文件:")
-	fmt.Println("     wechatmp2markdown batch [公众号目录路径] [--image=选项]")
+	fmt.Println("     wechatmp2markdown batch [公众号目录路径] [--image=选项] [--format=格式]")
 	fmt.Println("     例如: wechatmp2markdown batch D:\\WechatDownload\\浙江宣传 --image=save")
 	fmt.Println("\n  6. 批量转换HTML文件为TXT:")
 	fmt.Println("     wechatmp2markdown batchTxt [公众号目录路径]")
@@ -246,8 +566,45 @@ func printUsage() {
 	fmt.Println("\n  7. 从本地HTML文件转换为TXT:")
 	fmt.Println("     wechatmp2markdown fileTxt [HTML文件路径] [输出路径]")
 	fmt.Println("     例如: wechatmp2markdown fileTxt ./article.html ./output")
+	fmt.Println("\n  8. 把公众号目录合成EPUB电子书(每个公众号一本，按发布时间排序):")
+	fmt.Println("     wechatmp2markdown epub [公众号目录路径] [输出目录] [--image=选项]")
+	fmt.Println("     例如: wechatmp2markdown epub D:\\WechatDownload\\浙江宣传 D:\\books")
 	fmt.Println("\n图片选项:")
 	fmt.Println("  --image=url    只保留图片URL链接")
 	fmt.Println("  --image=save   保存图片到本地")
 	fmt.Println("  --image=base64 将图片转换为base64编码嵌入Markdown (默认)")
+	fmt.Println("\n输出格式:")
+	fmt.Println("  --format=markdown 输出Markdown (默认，batchTxt/fileTxt 默认为text)")
+	fmt.Println("  --format=text     输出纯文本")
+	fmt.Println("  --format=html     输出可离线打开的独立HTML，图片随--image内嵌或保存在旁边")
+	fmt.Println("  --format=epub     输出EPUB电子书，图片打包在书内")
+	fmt.Println("  --format=docx     输出Word文档，图片嵌在文档内")
+	fmt.Println("  --format=org      输出Emacs org-mode，图片处理方式与Markdown相同")
+	fmt.Println("  --format=asciidoc 输出AsciiDoc (也可写作adoc)，图片处理方式与Markdown相同")
+	fmt.Println("  --format=rst      输出reStructuredText，图片处理方式与Markdown相同")
+	fmt.Println("\n其他选项:")
+	fmt.Println("  --header-threshold=1.15  由内联样式推断小标题的字号比例阈值，越小越激进，负数为不推断")
+	fmt.Println("  --follow-share           转载、分享的文章改为转换它指向的原文")
+	fmt.Println("  --image-workers=8        同时下载的图片数，相同地址的图片只下载一次")
+	fmt.Println("  --image-retries=2        图片下载失败(网络错误、超时、5xx、429)后按指数退避重试的次数，0为不重试")
+	fmt.Println("  --image-timeout=30s      单张图片每次下载的超时")
+	fmt.Println("  --image-cache[=目录]     下载的图片缓存到磁盘，再次转换时不用重新下载，不写目录时使用用户缓存目录")
+	fmt.Println("  --image-cache-size=1GB   图片缓存的大小上限，超过时删除最久没有用到的图片")
+	fmt.Println("  --user-agent=...         请求的User-Agent")
+	fmt.Println("  --referer=URL|none       下载图片时的Referer，默认为https://mp.weixin.qq.com/；拿到防盗链占位图时会换一个Referer重试")
+	fmt.Println("  --placeholder-hash=...   已知的防盗链占位图的sha256，多个以逗号分隔，不指定时自动识别")
+	fmt.Println("  --header=\"Name: value\"   额外的请求头，可以写多个")
+	fmt.Println("  --cookie=\"a=1; b=2\"      请求公众号页面时带上的cookie")
+	fmt.Println("  --proxy=URL              http://、https://或socks5://代理，默认使用环境变量HTTP_PROXY/HTTPS_PROXY")
+	fmt.Println("  --insecure               不校验TLS证书；--ca-cert=ca.pem 额外信任的CA证书")
+	fmt.Println("  --timeout=30s            请求文章页面的超时")
+	fmt.Println("  --record=目录            把文章页面和图片的响应录制到目录，用于制作回归用例")
+	fmt.Println("  --replay=目录            从--record录制的目录回放，不访问网络")
+	fmt.Println("  --media=link|html5       视频、音频输出为带封面的链接(默认)或html5标签")
+	fmt.Println("  --flavor=commonmark|gfm|obsidian|typora|hexo|logseq")
+	fmt.Println("                           Markdown方言，整体切换换行、图片、表格、高亮、引用和front matter的写法")
+	fmt.Println("  --front-matter=yaml|toml|properties|none")
+	fmt.Println("                           在文件开头输出front matter，未指定时按--flavor的习惯(obsidian/hexo为yaml，logseq为properties)")
+	fmt.Println("  --front-matter-fields=title,date:publishDate,tags")
+	fmt.Println("                           front matter的字段，冒号后为重命名，可选: title/author/account/date/tags/cover/url/source/digest")
 }
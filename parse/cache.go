@@ -0,0 +1,212 @@
+package parse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultImageCacheSize 图片缓存默认的大小上限
+const DefaultImageCacheSize int64 = 1 << 30
+
+const imageCacheIndex = "index.json"
+
+// ImageCache 磁盘上的图片缓存：图片按内容的 sha256 存放（相同内容只存一份），
+// index.json 记录 url => 哈希。总大小超过上限时淘汰最久没有用到的图片
+type ImageCache struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	entries map[string]imageCacheEntry // url => 缓存项
+	refs    map[string]int             // 哈希 => 指向这张图片的 url 数
+	size    int64                      // 所有图片文件的总大小
+	dirty   bool                       // 索引有改动还没有写回
+}
+
+type imageCacheEntry struct {
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+	Used int64  `json:"used"` // 最近一次用到的时间（unix 秒）
+}
+
+// DefaultImageCacheDir 图片缓存的默认目录：用户缓存目录下的 wechatmp2markdown/images
+func DefaultImageCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wechatmp2markdown", "images"), nil
+}
+
+// NewImageCache 打开（不存在时创建）dir 下的图片缓存，maxSize 为0时使用 DefaultImageCacheSize
+func NewImageCache(dir string, maxSize int64) (*ImageCache, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultImageCacheSize
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	cache := &ImageCache{dir: dir, maxSize: maxSize, entries: make(map[string]imageCacheEntry), refs: make(map[string]int)}
+	data, err := os.ReadFile(filepath.Join(dir, imageCacheIndex))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		// 索引损坏时当作空缓存，图片文件在之后的淘汰中不会被统计，但不影响使用
+		if json.Unmarshal(data, &cache.entries) != nil {
+			cache.entries = make(map[string]imageCacheEntry)
+		}
+		for url, entry := range cache.entries {
+			if len(entry.Hash) != sha256.Size*2 {
+				delete(cache.entries, url)
+			}
+		}
+	}
+	for _, entry := range cache.entries {
+		cache.addRef(entry)
+	}
+	// 上限可能比上次小
+	cache.evict()
+	return cache, nil
+}
+
+// Get 取出 url 对应的图片，未缓存或文件已丢失、损坏时返回 false。读文件时不持有锁，不阻塞其它下载
+func (c *ImageCache) Get(url string) ([]byte, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[url]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.blobPath(entry.Hash))
+	valid := err == nil && imageHash(data) == entry.Hash
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 读文件期间缓存项可能已被其它 goroutine 替换或淘汰
+	if current, ok := c.entries[url]; !ok || current.Hash != entry.Hash {
+		if valid {
+			return data, true
+		}
+		return nil, false
+	}
+	if !valid {
+		c.remove(url)
+		return nil, false
+	}
+	entry = c.entries[url]
+	entry.Used = time.Now().Unix()
+	c.entries[url] = entry
+	c.dirty = true
+	return data, true
+}
+
+// Put 缓存 url 对应的图片，超过上限时淘汰最久没有用到的图片。比上限还大的图片不缓存
+func (c *ImageCache) Put(url string, data []byte) error {
+	size := int64(len(data))
+	if size == 0 || size > c.maxSize {
+		return nil
+	}
+	hash := imageHash(data)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[url]; ok && old.Hash == hash {
+		return nil
+	}
+	path := c.blobPath(hash)
+	if _, err := os.Stat(path); err != nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		// 先写临时文件再改名，避免中断时留下不完整的图片
+		if err := writeFileAtomic(path, data); err != nil {
+			return err
+		}
+	}
+	c.remove(url)
+	entry := imageCacheEntry{hash, size, time.Now().Unix()}
+	c.entries[url] = entry
+	c.addRef(entry)
+	c.dirty = true
+	c.evict()
+	return nil
+}
+
+// Flush 把索引写回磁盘
+func (c *ImageCache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, imageCacheIndex), data); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// evict 按最近用到的时间从旧到新删除，直到总大小不超过上限
+func (c *ImageCache) evict() {
+	if c.size <= c.maxSize {
+		return
+	}
+	urls := make([]string, 0, len(c.entries))
+	for url := range c.entries {
+		urls = append(urls, url)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return c.entries[urls[i]].Used < c.entries[urls[j]].Used
+	})
+	for _, url := range urls {
+		if c.size <= c.maxSize {
+			break
+		}
+		c.remove(url)
+	}
+}
+
+// remove 删除 url 的缓存项，没有其他 url 指向同一张图片时一并删除图片文件
+func (c *ImageCache) remove(url string) {
+	entry, ok := c.entries[url]
+	if !ok {
+		return
+	}
+	delete(c.entries, url)
+	c.dirty = true
+	c.refs[entry.Hash]--
+	if c.refs[entry.Hash] <= 0 {
+		delete(c.refs, entry.Hash)
+		os.Remove(c.blobPath(entry.Hash))
+		c.size -= entry.Size
+	}
+}
+
+// addRef 记录一个指向 entry.Hash 的 url，图片第一次被引用时计入总大小
+func (c *ImageCache) addRef(entry imageCacheEntry) {
+	if c.refs[entry.Hash] == 0 {
+		c.size += entry.Size
+	}
+	c.refs[entry.Hash]++
+}
+
+// blobPath 图片文件按哈希的前两位分目录存放
+func (c *ImageCache) blobPath(hash string) string {
+	return filepath.Join(c.dir, hash[:2], hash)
+}
+
+func imageHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
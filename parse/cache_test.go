@@ -0,0 +1,112 @@
+package parse
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestImageCacheSharedContent(t *testing.T) {
+	cache, err := NewImageCache(t.TempDir(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := bytes.Repeat([]byte("a"), 40)
+	cache.Put("https://a/1", img)
+	cache.Put("https://a/2", img)
+	if cache.size != 40 || cache.refs[imageHash(img)] != 2 {
+		t.Fatalf("size = %d, refs = %d, want one shared blob of 40 bytes", cache.size, cache.refs[imageHash(img)])
+	}
+	// 换成其它内容后，另一个 url 仍然能取到原来的图片
+	cache.Put("https://a/1", bytes.Repeat([]byte("b"), 10))
+	if data, ok := cache.Get("https://a/2"); !ok || !bytes.Equal(data, img) {
+		t.Fatal("shared blob was removed while still referenced")
+	}
+	if cache.size != 50 {
+		t.Errorf("size = %d, want 50", cache.size)
+	}
+}
+
+func TestImageCacheEvict(t *testing.T) {
+	cache, err := NewImageCache(t.TempDir(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Put("https://a/old", bytes.Repeat([]byte("o"), 60))
+	cache.entries["https://a/old"] = imageCacheEntry{cache.entries["https://a/old"].Hash, 60, 1}
+	cache.Put("https://a/new", bytes.Repeat([]byte("n"), 60))
+	if _, ok := cache.Get("https://a/old"); ok {
+		t.Error("least recently used image should be evicted")
+	}
+	if _, ok := cache.Get("https://a/new"); !ok {
+		t.Error("newest image should stay cached")
+	}
+	if cache.size != 60 {
+		t.Errorf("size = %d, want 60", cache.size)
+	}
+}
+
+func TestImageCacheCorruptBlob(t *testing.T) {
+	cache, err := NewImageCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := []byte("image")
+	cache.Put("https://a/1", img)
+	os.WriteFile(cache.blobPath(imageHash(img)), []byte("broken"), 0644)
+	if _, ok := cache.Get("https://a/1"); ok {
+		t.Fatal("corrupt blob should not be returned")
+	}
+	if _, ok := cache.entries["https://a/1"]; ok || cache.size != 0 {
+		t.Error("corrupt entry should be removed")
+	}
+}
+
+func TestImageCacheReopen(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewImageCache(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Put("https://a/1", []byte("one"))
+	cache.Put("https://a/2", []byte("one"))
+	if err := cache.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewImageCache(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := reopened.Get("https://a/2"); !ok || string(data) != "one" {
+		t.Fatal("cached image lost after reopening")
+	}
+	if reopened.size != 3 || reopened.refs[imageHash([]byte("one"))] != 2 {
+		t.Errorf("size = %d, refs = %v after reopening", reopened.size, reopened.refs)
+	}
+}
+
+func TestImageCacheConcurrent(t *testing.T) {
+	cache, err := NewImageCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := "https://a/" + string(rune('a'+i%2))
+			cache.Put(url, []byte{byte(i)})
+			cache.Get(url)
+		}(i)
+	}
+	wg.Wait()
+	var size int64
+	for range cache.refs {
+		size++
+	}
+	if cache.size != size {
+		t.Errorf("size = %d, want %d (one byte per referenced blob)", cache.size, size)
+	}
+}
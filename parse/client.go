@@ -0,0 +1,291 @@
+package parse
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultUserAgent 默认的 User-Agent
+	DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36 Edg/133.0.0.0"
+	// WechatReferer 公众平台的地址，mmbiz 图片只允许这个 Referer 或不带 Referer 引用
+	WechatReferer = "https://mp.weixin.qq.com/"
+	// placeholderProbeReferer 其他网站的地址，带着它请求 mmbiz 图片一定得到防盗链占位图
+	placeholderProbeReferer = "https://example.com/"
+	// DefaultPageTimeout 默认的文章页面请求超时
+	DefaultPageTimeout = 30 * time.Second
+)
+
+// ClientOptions 请求文章页面和下载图片的 HTTP 设置
+type ClientOptions struct {
+	// UserAgent 为空时使用 DefaultUserAgent
+	UserAgent string
+	// Referer 下载图片时的 Referer，为空时使用 WechatReferer，为 "none" 时不带 Referer
+	Referer string
+	// Headers 额外的请求头，会覆盖上面的设置
+	Headers map[string]string
+	// Cookies 初始的 cookie，格式与请求头相同："name=value; name2=value2"，对 mp.weixin.qq.com 生效；
+	// 之后响应设置的 cookie 由 cookie jar 保存，同一个 Client 的请求共用
+	Cookies string
+	// Proxy 代理地址，支持 http://、https://、socks5://，为空时使用环境变量 HTTP_PROXY、HTTPS_PROXY
+	Proxy string
+	// Insecure 不校验 TLS 证书
+	Insecure bool
+	// CACertFile 额外信任的 CA 证书（PEM）文件
+	CACertFile string
+	// Timeout 文章页面的请求超时，为0时使用 DefaultPageTimeout；图片的超时见 Options.ImageTimeout
+	Timeout time.Duration
+	// PlaceholderHashes 已知的防盗链占位图的 sha256。为空时第一次下载到公众平台的图片后，
+	// 用其他网站的 Referer 请求一次，从返回的内容认出占位图
+	PlaceholderHashes []string
+}
+
+// Fetcher 解析时所有的网络请求（文章页面和图片）都通过它发出，可以替换成录制、回放等实现。
+// *http.Client 和 *Client 都实现了 Fetcher
+type Fetcher interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client 按 ClientOptions 发请求的 Fetcher，可以在多篇文章之间共用，以共享连接、cookie 和识别出来的占位图
+type Client struct {
+	opts         ClientOptions
+	client       *http.Client
+	placeholders *placeholderSet
+}
+
+// NewClient 按 opts 创建 Client，代理地址或证书文件无效时返回错误
+func NewClient(opts ClientOptions) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", opts.Proxy)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.Insecure || opts.CACertFile != "" {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.Insecure}
+		if opts.CACertFile != "" {
+			pem, err := os.ReadFile(opts.CACertFile)
+			if err != nil {
+				return nil, err
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificate found in %s", opts.CACertFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Cookies != "" {
+		header := http.Header{"Cookie": {opts.Cookies}}
+		cookies := (&http.Request{Header: header}).Cookies()
+		wechatURL, _ := url.Parse(WechatReferer)
+		jar.SetCookies(wechatURL, cookies)
+	}
+	return &Client{
+		opts:         opts,
+		client:       &http.Client{Transport: transport, Jar: jar},
+		placeholders: newPlaceholderSet(opts.PlaceholderHashes),
+	}, nil
+}
+
+// Do 按设置替换 User-Agent，加上额外的请求头后发出请求
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+	for name, value := range c.opts.Headers {
+		req.Header.Set(name, value)
+	}
+	return c.client.Do(req)
+}
+
+var (
+	defaultClient     *Client
+	defaultClientOnce sync.Once
+)
+
+// fetcher 未指定 Fetcher 时使用默认设置的 Client
+func (opts *Options) fetcher() Fetcher {
+	if opts.Fetcher != nil {
+		return opts.Fetcher
+	}
+	defaultClientOnce.Do(func() {
+		defaultClient, _ = NewClient(ClientOptions{})
+	})
+	return defaultClient
+}
+
+// clientOptions Fetcher 是 *Client（或包装了 *Client 的录制器）时取它的设置，是回放器时取录制时的设置，否则为默认设置
+func clientOptions(f Fetcher) ClientOptions {
+	switch f := f.(type) {
+	case *Client:
+		return f.opts
+	case *Recorder:
+		return clientOptions(f.next)
+	case *Replayer:
+		return f.opts
+	}
+	return ClientOptions{}
+}
+
+// imageReferer 下载图片时使用的 Referer
+func imageReferer(f Fetcher) string {
+	switch referer := clientOptions(f).Referer; referer {
+	case "":
+		return WechatReferer
+	case "none":
+		return ""
+	default:
+		return referer
+	}
+}
+
+// newRequest 带上默认 User-Agent 和 referer 的 GET 请求
+func newRequest(ctx context.Context, url string, referer string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	return req, nil
+}
+
+// getPage 请求文章页面，调用方负责关闭 Body
+func getPage(f Fetcher, url string) (*http.Response, error) {
+	timeout := clientOptions(f).Timeout
+	if timeout <= 0 {
+		timeout = DefaultPageTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	req, err := newRequest(ctx, url, "")
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res, err := f.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = cancelOnClose{res.Body, cancel}
+	return res, nil
+}
+
+// cancelOnClose 读完页面关闭 Body 时才取消超时的 context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// placeholderSet 一个 Client 已知的防盗链占位图，以及确认过不是占位图、只是多个地址引用了同一张图的内容
+type placeholderSet struct {
+	mu      sync.Mutex
+	hashes  map[string]bool
+	genuine map[string]bool
+	probed  bool
+}
+
+func newPlaceholderSet(hashes []string) *placeholderSet {
+	set := &placeholderSet{hashes: make(map[string]bool), genuine: make(map[string]bool)}
+	for _, hash := range hashes {
+		set.hashes[strings.ToLower(strings.TrimSpace(hash))] = true
+	}
+	return set
+}
+
+// has 图片内容是否是已知的防盗链占位图
+func (s *placeholderSet) has(data []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.hashes) > 0 && s.hashes[imageHash(data)]
+}
+
+// add 记下识别出来的防盗链占位图，之后的文章即使只有一张图被替换也能认出来
+func (s *placeholderSet) add(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashes[hash] = true
+	delete(s.genuine, hash)
+}
+
+// isGenuine 是否已经确认过这个内容不是占位图
+func (s *placeholderSet) isGenuine(hash string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.genuine[hash]
+}
+
+// addGenuine 记下换了 Referer 内容也不变的图片，之后不再重新下载
+func (s *placeholderSet) addGenuine(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.hashes[hash] {
+		s.genuine[hash] = true
+	}
+}
+
+// startProbe 还不知道任何占位图、也没有试过时返回 true，由调用方去取得占位图；每个 Client 只试一次
+func (s *placeholderSet) startProbe() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.hashes) > 0 || s.probed {
+		return false
+	}
+	s.probed = true
+	return true
+}
+
+// placeholdersOf Fetcher 是 *Client（或包装了 *Client 的录制器）时用它的占位图集合，
+// 否则只在这一次解析中有效
+func placeholdersOf(f Fetcher) *placeholderSet {
+	switch f := f.(type) {
+	case *Client:
+		return f.placeholders
+	case *Recorder:
+		return placeholdersOf(f.next)
+	}
+	return newPlaceholderSet(nil)
+}
+
+// isWechatImage 是否是公众平台的图片（mmbiz.qpic.cn 等），只有它们有防盗链
+func isWechatImage(src string) bool {
+	u, err := url.Parse(src)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.HasPrefix(host, "mmbiz") && (strings.HasSuffix(host, ".qpic.cn") || strings.HasSuffix(host, ".qlogo.cn"))
+}
@@ -0,0 +1,176 @@
+package parse
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeImages 按 url 和 Referer 返回图片的 RoundTripper：带其他网站的 Referer 时都返回占位图，
+// 带 WechatReferer 时 hotlinked 中的地址也返回占位图
+type fakeImages struct {
+	mu        sync.Mutex
+	images    map[string]string
+	hotlinked map[string]bool
+	requests  int
+}
+
+var placeholderImage = "placeholder"
+
+func (f *fakeImages) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	body, ok := f.images[req.URL.String()]
+	status := 200
+	if !ok {
+		status = 404
+	} else if referer := req.Header.Get("Referer"); referer != "" && (referer != WechatReferer || f.hotlinked[req.URL.String()]) {
+		body = placeholderImage
+	}
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+// newFakeClient 按 opts 创建的 Client，请求都交给 transport
+func newFakeClient(t *testing.T, opts ClientOptions, transport http.RoundTripper) *Client {
+	t.Helper()
+	client, err := NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.client.Transport = transport
+	return client
+}
+
+func fetchedData(t *testing.T, pieces []Piece) []string {
+	t.Helper()
+	var res []string
+	for _, piece := range pieces {
+		data, _ := piece.Val.([]byte)
+		res = append(res, string(data))
+	}
+	return res
+}
+
+const (
+	imgA = "https://mmbiz.qpic.cn/mmbiz_png/a/640"
+	imgB = "https://mmbiz.qpic.cn/mmbiz_png/b/640"
+)
+
+func TestPlaceholdersLearnedPerClient(t *testing.T) {
+	images := &fakeImages{
+		images:    map[string]string{imgA: "a", imgB: "b"},
+		hotlinked: map[string]bool{imgA: true, imgB: true},
+	}
+	client := newFakeClient(t, ClientOptions{}, images)
+	opts := Options{ImagePolicy: IMAGE_POLICY_SAVE, ImageRetries: -1, Fetcher: client}
+	pieces, err := fetchImages(imagePieces(imgA, imgB), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fetchedData(t, pieces); got[0] != "a" || got[1] != "b" {
+		t.Fatalf("images = %v, want [a b]", got)
+	}
+	if !client.placeholders.has([]byte(placeholderImage)) {
+		t.Fatal("placeholder should be learned by the client")
+	}
+	// 同一个 Client 认出了占位图，之后只有一张图被替换也能换 Referer 重新下载
+	if pieces, err = fetchImages(imagePieces(imgA), &opts); err != nil || fetchedData(t, pieces)[0] != "a" {
+		t.Fatalf("single hotlinked image on the same client: %v, %v", fetchedData(t, pieces), err)
+	}
+	// 其它 Client 不受影响
+	other := newFakeClient(t, ClientOptions{}, images)
+	if other.placeholders.has([]byte(placeholderImage)) {
+		t.Error("learned placeholder leaked into another client")
+	}
+}
+
+func TestPlaceholderDetectedByDefault(t *testing.T) {
+	images := &fakeImages{
+		images:    map[string]string{imgA: "a", imgB: "b"},
+		hotlinked: map[string]bool{imgB: true},
+	}
+	client := newFakeClient(t, ClientOptions{}, images)
+	opts := Options{ImagePolicy: IMAGE_POLICY_SAVE, ImageRetries: -1, Fetcher: client}
+	pieces, err := fetchImages(imagePieces(imgA), &opts)
+	if err != nil || fetchedData(t, pieces)[0] != "a" {
+		t.Fatalf("image without hotlink protection: %v, %v", fetchedData(t, pieces), err)
+	}
+	if !client.placeholders.has([]byte(placeholderImage)) {
+		t.Fatal("placeholder should be detected without any known hash")
+	}
+	// 文中只有一张图被替换
+	images.requests = 0
+	if pieces, err = fetchImages(imagePieces(imgB), &opts); err != nil || fetchedData(t, pieces)[0] != "b" {
+		t.Fatalf("single hotlinked image: %v, %v", fetchedData(t, pieces), err)
+	}
+	if images.requests != 2 {
+		t.Errorf("made %d requests, want 2 (the placeholder is probed only once)", images.requests)
+	}
+
+	// 第一张就是占位图时也能认出来
+	client = newFakeClient(t, ClientOptions{}, images)
+	opts.Fetcher = client
+	if pieces, err = fetchImages(imagePieces(imgB), &opts); err != nil || fetchedData(t, pieces)[0] != "b" {
+		t.Fatalf("single hotlinked image on a new client: %v, %v", fetchedData(t, pieces), err)
+	}
+	if !client.placeholders.has([]byte(placeholderImage)) {
+		t.Error("placeholder should be learned from the first image")
+	}
+}
+
+func TestPlaceholderHashesOption(t *testing.T) {
+	images := &fakeImages{images: map[string]string{imgA: "a"}, hotlinked: map[string]bool{imgA: true}}
+	client := newFakeClient(t, ClientOptions{PlaceholderHashes: []string{strings.ToUpper(imageHash([]byte(placeholderImage)))}}, images)
+	opts := Options{ImagePolicy: IMAGE_POLICY_SAVE, ImageRetries: -1, Fetcher: client}
+	pieces, err := fetchImages(imagePieces(imgA), &opts)
+	if err != nil || fetchedData(t, pieces)[0] != "a" {
+		t.Fatalf("known placeholder should be refetched: %v, %v", fetchedData(t, pieces), err)
+	}
+
+	// 换了 Referer 还是占位图时报错
+	images.hotlinked[imgA] = false
+	images.images[imgA] = placeholderImage
+	if _, err := fetchImages(imagePieces(imgA), &opts); err == nil {
+		t.Error("placeholder that stays a placeholder should be an error")
+	}
+}
+
+func TestSharedImageRefetchedOnce(t *testing.T) {
+	images := &fakeImages{images: map[string]string{imgA: "same", imgB: "same"}}
+	client := newFakeClient(t, ClientOptions{}, images)
+	opts := Options{ImagePolicy: IMAGE_POLICY_SAVE, ImageRetries: -1, Fetcher: client}
+	if _, err := fetchImages(imagePieces(imgA, imgB), &opts); err != nil {
+		t.Fatal(err)
+	}
+	if images.requests != 4 {
+		t.Errorf("first run made %d requests, want 4 (two downloads, a probe for the placeholder and one check)", images.requests)
+	}
+	images.requests = 0
+	if _, err := fetchImages(imagePieces(imgA, imgB), &opts); err != nil {
+		t.Fatal(err)
+	}
+	if images.requests != 2 {
+		t.Errorf("second run made %d requests, want 2", images.requests)
+	}
+
+	// 取自缓存的图片不再检查
+	cache, err := NewImageCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Put(imgA, []byte("same"))
+	cache.Put(imgB, []byte("same"))
+	images.requests = 0
+	opts = Options{ImagePolicy: IMAGE_POLICY_SAVE, ImageRetries: -1, Fetcher: newFakeClient(t, ClientOptions{}, images), ImageCache: cache}
+	pieces, err := fetchImages(imagePieces(imgA, imgB), &opts)
+	if err != nil || !bytes.Equal(pieces[1].Val.([]byte), []byte("same")) {
+		t.Fatalf("cached images: %v, %v", fetchedData(t, pieces), err)
+	}
+	if images.requests != 0 {
+		t.Errorf("cached run made %d requests, want 0", images.requests)
+	}
+}
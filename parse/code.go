@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 行号栏的class，公众号代码块插件和 highlight.js 等各有各的写法
+var lineNumberClassReg = regexp.MustCompile(`line-index|line-?num|line-number|hljs-ln-numbers|gutter`)
+
+// 代码块语言的class，如 language-go、lang-go
+var langClassReg = regexp.MustCompile(`(?:^|\s)(?:language|lang)-([\w+#-]+)`)
+
+// parsePre 解析代码块。公众号代码块插件每一行是一个 code，其它编辑器则是一整个 code（或直接是 pre）用换行或 br 分行。
+// 代码块中间夹着图片时，在图片处把代码块断开，图片照常输出
+func parsePre(s *goquery.Selection, opts *Options) ([]Piece, error) {
+	var pieces []Piece
+	var rows []string
+	var line string
+	var lastIsBr bool
+	attr := map[string]string{"lang": codeLang(s)}
+
+	flushCode := func() {
+		rows = trimBlankRows(rows)
+		if len(rows) > 0 {
+			pieces = append(pieces, Piece{CODE_BLOCK, rows, attr})
+		}
+		rows = nil
+	}
+	endLine := func() {
+		rows = append(rows, line)
+		line = ""
+	}
+
+	// 公众号代码块插件：每个 code 是一行，code 之外的空白和换行都不算代码
+	perLine := s.Find("code").Length() > 1
+
+	var err error
+	var walk func(sel *goquery.Selection, inCode bool) bool
+	walk = func(sel *goquery.Selection, inCode bool) bool {
+		sel.Contents().EachWithBreak(func(i int, sc *goquery.Selection) bool {
+			switch {
+			case goquery.NodeName(sc) == "#text":
+				if perLine && !inCode {
+					break
+				}
+				// &nbsp; 缩进换回普通空格
+				text := strings.ReplaceAll(sc.Text(), "\u00a0", " ")
+				parts := strings.Split(text, "\n")
+				line += parts[0]
+				for _, part := range parts[1:] {
+					endLine()
+					line = part
+				}
+				lastIsBr = false
+			case sc.Is("br"):
+				if perLine && !inCode {
+					break
+				}
+				endLine()
+				lastIsBr = true
+			case sc.Is("img"):
+				if line != "" {
+					endLine()
+				}
+				flushCode()
+				var image Piece
+				if image, err = parseImage(sc, opts); err != nil {
+					return false
+				}
+				pieces = append(pieces, image)
+				lastIsBr = true
+			case isLineNumber(sc):
+				// 跳过行号栏
+			case perLine && sc.Is("code"), sc.Is("tr"):
+				// 每个 code（或表格的每一行）是单独的一行
+				lastIsBr = false
+				if !walk(sc, true) {
+					return false
+				}
+				if line != "" || !lastIsBr {
+					endLine()
+				}
+				lastIsBr = true
+			default:
+				if !walk(sc, inCode) {
+					return false
+				}
+			}
+			return true
+		})
+		return err == nil
+	}
+	if !walk(s, false) {
+		return nil, err
+	}
+	if line != "" {
+		endLine()
+	}
+	flushCode()
+	return pieces, nil
+}
+
+// codeLang 从 data-lang 属性或 language-xxx 类名中取得代码的语言
+func codeLang(s *goquery.Selection) string {
+	var lang string
+	s.Find("[data-lang],[class]").AddBack().EachWithBreak(func(i int, sc *goquery.Selection) bool {
+		if dataLang, exists := sc.Attr("data-lang"); exists && strings.TrimSpace(dataLang) != "" {
+			lang = dataLang
+			return false
+		}
+		class, _ := sc.Attr("class")
+		if m := langClassReg.FindStringSubmatch(class); m != nil {
+			lang = m[1]
+			return false
+		}
+		return true
+	})
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	switch lang {
+	case "plaintext", "plain", "text", "txt", "nohighlight":
+		return ""
+	}
+	return lang
+}
+
+func isLineNumber(s *goquery.Selection) bool {
+	class, _ := s.Attr("class")
+	return class != "" && lineNumberClassReg.MatchString(class)
+}
+
+// trimBlankRows 去掉首尾的空行
+func trimBlankRows(rows []string) []string {
+	for len(rows) > 0 && strings.TrimSpace(rows[0]) == "" {
+		rows = rows[1:]
+	}
+	for len(rows) > 0 && strings.TrimSpace(rows[len(rows)-1]) == "" {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
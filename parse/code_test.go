@@ -0,0 +1,37 @@
+package parse
+
+import "testing"
+
+func TestParsePre(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"newlines", "<pre><code class=\"language-go\">a := 1\n\nb := 2\n</code></pre>", `pre:go(a := 1\n\nb := 2)`},
+		{"br lines", `<pre><code>x<br>&nbsp;&nbsp;y<br></code></pre>`, `pre:(x\n  y)`},
+		{"data-lang", `<pre data-lang="Python"><code>print(1)</code></pre>`, `pre:python(print(1))`},
+		{"plaintext", `<pre class="lang-plaintext"><code>text</code></pre>`, `pre:(text)`},
+		{
+			"code snippet per line with gutter",
+			`<section class="code-snippet__fix code-snippet__js"><ul class="code-snippet__line-index"><li></li><li></li></ul>` +
+				`<pre class="code-snippet__js" data-lang="javascript"><code><span>let a = 1;</span></code><code><span></span></code><code><span>a++;</span></code></pre></section>`,
+			`pre:javascript(let a = 1;\n\na++;)`,
+		},
+		{
+			"split around image",
+			`<pre><code>before<br><img data-src="https://a.com/1.png"><br>after</code></pre>`,
+			`pre:(before) image(https://a.com/1.png) pre:(after)`,
+		},
+		{
+			"table rows",
+			`<pre><table><tr><td class="hljs-ln-numbers">1</td><td>one</td></tr><tr><td class="hljs-ln-numbers">2</td><td>two</td></tr></table></pre>`,
+			`pre:(one\ntwo)`,
+		},
+	}
+	for _, tt := range tests {
+		if got := describe(parseContent(t, tt.html, Options{})); got != tt.want {
+			t.Errorf("%s\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package parse
+
+import "errors"
+
+var (
+	// ErrNotFound 文章页面或本地文件不存在
+	ErrNotFound = errors.New("article not found")
+	// ErrImageFetch 图片下载失败
+	ErrImageFetch = errors.New("fetch image failed")
+	// ErrNoContent 页面中找不到文章正文
+	ErrNoContent = errors.New("article has no content")
+	// ErrUnavailable 文章页面打不开，具体原因见 UnavailableError
+	ErrUnavailable = errors.New("article unavailable")
+	// ErrDeleted 文章已被发布者删除，或公众号已注销
+	ErrDeleted = errors.New("article deleted")
+	// ErrBanned 文章或公众号因违规、投诉被停止访问
+	ErrBanned = errors.New("article banned")
+	// ErrExpired 链接已过期（如临时链接）
+	ErrExpired = errors.New("article link expired")
+	// ErrVerify 微信要求验证（“环境异常”页面），一般是请求过于频繁
+	ErrVerify = errors.New("verification required")
+	// ErrFixtureMissing 回放时录制目录中没有这个请求
+	ErrFixtureMissing = errors.New("no recorded response")
+)
+
+// UnavailableError 文章页面打不开：Kind 为 ErrDeleted、ErrBanned、ErrExpired、ErrVerify 之一，
+// 未能归类时为 ErrUnavailable；Reason 为页面上微信给出的说明。errors.Is 对 Kind 和 ErrUnavailable 都成立
+type UnavailableError struct {
+	Kind   error
+	Reason string
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Reason == "" {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Reason
+}
+
+func (e *UnavailableError) Unwrap() []error {
+	return []error{e.Kind, ErrUnavailable}
+}
@@ -0,0 +1,283 @@
+package parse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 图片下载的默认参数
+const (
+	// DefaultImageWorkers 默认同时下载的图片数
+	DefaultImageWorkers = 8
+	// DefaultImageRetries 默认的重试次数
+	DefaultImageRetries = 2
+	// DefaultImageTimeout 默认的单张图片下载超时
+	DefaultImageTimeout = 30 * time.Second
+	// 第一次重试前的等待时间，之后每次翻倍
+	imageRetryBackoff = 500 * time.Millisecond
+)
+
+// errRetryable 可以重试的下载错误：网络错误、超时、5xx 和 429
+type errRetryable struct {
+	err error
+}
+
+func (e errRetryable) Error() string {
+	return e.err.Error()
+}
+
+func (e errRetryable) Unwrap() error {
+	return e.err
+}
+
+// fetchImages 正文解析完后统一下载图片：相同的地址只下载一次，最多 ImageWorkers 张同时下载，失败时按指数退避重试。
+// 下载完按 ImagePolicy 把图片 piece 换成带内容的 IMAGE 或 IMAGE_BASE64，有图片下载失败时返回文中第一个失败的错误
+func fetchImages(pieces []Piece, opts *Options) ([]Piece, error) {
+	var urls []string
+	seen := make(map[string]bool)
+	walkImages(pieces, func(piece Piece) Piece {
+		if src := piece.Attrs["src"]; !seen[src] {
+			seen[src] = true
+			urls = append(urls, src)
+		}
+		return piece
+	})
+	if len(urls) == 0 {
+		return pieces, nil
+	}
+
+	workers := opts.ImageWorkers
+	if workers <= 0 {
+		workers = DefaultImageWorkers
+	}
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	placeholders := placeholdersOf(opts.fetcher())
+	results := make(map[string]imageResult, len(urls))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range queue {
+				res := fetchImage(ctx, url, placeholders, opts)
+				if res.err != nil {
+					// 有一张失败整篇就失败了，剩下的不用再下载
+					cancel()
+				}
+				mu.Lock()
+				results[url] = res
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, url := range urls {
+		queue <- url
+	}
+	close(queue)
+	wg.Wait()
+
+	// 按文中的顺序找第一个失败的，因取消而没有下载的不算
+	for _, url := range urls {
+		if err := results[url].err; err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	if err := fixPlaceholders(ctx, urls, results, placeholders, opts); err != nil {
+		return nil, err
+	}
+	if opts.ImageCache != nil {
+		for _, url := range urls {
+			if res := results[url]; res.fresh {
+				opts.ImageCache.Put(url, res.data)
+			}
+		}
+		// 缓存只是为了少下载，写不进去不影响本次转换
+		opts.ImageCache.Flush()
+	}
+	return walkImages(pieces, func(piece Piece) Piece {
+		data := results[piece.Attrs["src"]].data
+		attr := make(map[string]string, len(piece.Attrs)+3)
+		for k, v := range piece.Attrs {
+			attr[k] = v
+		}
+		setImageAttrs(attr, data)
+		if opts.ImagePolicy == IMAGE_POLICY_SAVE {
+			return Piece{IMAGE, data, attr}
+		}
+		return Piece{IMAGE_BASE64, img2base64(data), attr}
+	}), nil
+}
+
+// imageResult 一张图片的下载结果，fresh 表示是刚下载的而不是取自缓存
+type imageResult struct {
+	data  []byte
+	fresh bool
+	err   error
+}
+
+// fetchImage 有缓存时先查缓存，否则按设置的 Referer 下载
+func fetchImage(ctx context.Context, url string, placeholders *placeholderSet, opts *Options) imageResult {
+	if opts.ImageCache != nil {
+		if data, ok := opts.ImageCache.Get(url); ok && !placeholders.has(data) {
+			return imageResult{data: data}
+		}
+	}
+	data, err := fetchImageWithRetry(ctx, url, imageReferer(opts.fetcher()), opts)
+	return imageResult{data, err == nil, err}
+}
+
+// fixPlaceholders 防盗链时 mmbiz 对不同的图片返回同一张“此图片来自微信公众平台 未经允许不可引用”的占位图。
+// 已知的占位图，以及不同地址新下载到相同内容的公众平台图片，换一个 Referer 重新下载：
+// 内容变了说明原来的是占位图，记下它；内容不变说明只是多处引用了同一张图，也记下，之后不再重新下载。
+// 还不知道占位图时先用其他网站的 Referer 请求一次，mmbiz 这时一定返回占位图，这样文中只有一张图被替换也能认出来。
+// 已知的占位图换了 Referer 还是占位图时报错。取自缓存的图片在放入缓存前已经检查过
+func fixPlaceholders(ctx context.Context, urls []string, results map[string]imageResult, placeholders *placeholderSet, opts *Options) error {
+	sameContent := make(map[string]int)
+	probe := ""
+	for _, url := range urls {
+		if isWechatImage(url) && results[url].fresh {
+			sameContent[imageHash(results[url].data)]++
+			if probe == "" {
+				probe = url
+			}
+		}
+	}
+	suspect := ""
+	if probe != "" && placeholders.startProbe() {
+		if data, err := fetchImageWithRetry(ctx, probe, placeholderProbeReferer, opts); err == nil {
+			if hash := imageHash(data); hash != imageHash(results[probe].data) {
+				placeholders.add(hash)
+			} else {
+				// 正常下载到的也是这个内容：要么它就是占位图，要么这张图没有防盗链，换 Referer 重新下载才能分辨
+				suspect = hash
+			}
+		}
+	}
+	referer := WechatReferer
+	if imageReferer(opts.fetcher()) == WechatReferer {
+		referer = ""
+	}
+	for _, url := range urls {
+		res := results[url]
+		if !isWechatImage(url) {
+			continue
+		}
+		hash := imageHash(res.data)
+		known := placeholders.has(res.data)
+		if !known && (!res.fresh || (sameContent[hash] < 2 && hash != suspect) || placeholders.isGenuine(hash)) {
+			continue
+		}
+		data, err := fetchImageWithRetry(ctx, url, referer, opts)
+		if err == nil && imageHash(data) != hash {
+			placeholders.add(hash)
+			results[url] = imageResult{data: data, fresh: true}
+		} else if err == nil && !known {
+			placeholders.addGenuine(hash)
+		} else if known {
+			return fmt.Errorf("%w: %s: got hotlink placeholder image", ErrImageFetch, url)
+		}
+	}
+	return nil
+}
+
+// walkImages 遍历所有待下载的图片（没有内容的 IMAGE），包括引用、列表和表格里的，返回用 fn 替换后的 pieces
+func walkImages(pieces []Piece, fn func(Piece) Piece) []Piece {
+	res := make([]Piece, len(pieces))
+	for i, piece := range pieces {
+		switch piece.Type {
+		case IMAGE:
+			if piece.Val == nil {
+				piece = fn(piece)
+			}
+		case TABLE:
+			if table, ok := piece.Val.(Table); ok {
+				rows := make([]TableRow, len(table.Rows))
+				for r, row := range table.Rows {
+					rows[r] = make(TableRow, len(row))
+					for c, cell := range row {
+						cell.Content = walkImages(cell.Content, fn)
+						rows[r][c] = cell
+					}
+				}
+				table.Rows = rows
+				piece.Val = table
+			}
+		default:
+			if sub, ok := piece.Val.([]Piece); ok {
+				piece.Val = walkImages(sub, fn)
+			}
+		}
+		res[i] = piece
+	}
+	return res
+}
+
+// fetchImageWithRetry 下载失败且可以重试时，等待 imageRetryBackoff、2*imageRetryBackoff…后重试
+func fetchImageWithRetry(ctx context.Context, url string, referer string, opts *Options) ([]byte, error) {
+	retries := opts.ImageRetries
+	if retries == 0 {
+		retries = DefaultImageRetries
+	}
+	timeout := opts.ImageTimeout
+	if timeout <= 0 {
+		timeout = DefaultImageTimeout
+	}
+	backoff := imageRetryBackoff
+	for attempt := 0; ; attempt++ {
+		data, err := fetchImgFile(ctx, opts.fetcher(), url, referer, timeout)
+		var retryable errRetryable
+		if err == nil || !errors.As(err, &retryable) || attempt >= retries {
+			return data, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func fetchImgFile(ctx context.Context, fetcher Fetcher, url string, referer string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := newRequest(ctx, url, referer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrImageFetch, url, err)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "image/avif,image/webp,image/apng,image/*,*/*;q=0.8")
+	}
+	res, err := fetcher.Do(req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, ErrFixtureMissing) {
+			return nil, err
+		}
+		return nil, errRetryable{fmt.Errorf("%w: %s: %v", ErrImageFetch, url, err)}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		err := fmt.Errorf("%w: %s: %d %s", ErrImageFetch, url, res.StatusCode, res.Status)
+		if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+			return nil, errRetryable{err}
+		}
+		return nil, err
+	}
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, errRetryable{fmt.Errorf("%w: %s: read response: %v", ErrImageFetch, url, err)}
+	}
+	return content, nil
+}
@@ -0,0 +1,171 @@
+package parse
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFetcher 按地址返回图片的 Fetcher：statuses 中的状态码依次用于该地址的前几次请求，之后返回 200。
+// 记录每个地址的请求次数和同时进行的最大请求数
+type fakeFetcher struct {
+	statuses map[string][]int
+	delay    time.Duration // 每次请求的耗时
+	block    bool          // 一直等到请求被取消
+
+	mu       sync.Mutex
+	requests map[string]int
+	inFlight int
+	peak     int
+}
+
+func (f *fakeFetcher) Do(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	f.mu.Lock()
+	if f.requests == nil {
+		f.requests = make(map[string]int)
+	}
+	n := f.requests[url]
+	f.requests[url]++
+	f.inFlight++
+	if f.inFlight > f.peak {
+		f.peak = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if f.block {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+	time.Sleep(f.delay)
+	status := 200
+	if n < len(f.statuses[url]) {
+		status = f.statuses[url][n]
+	}
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Header: http.Header{}, Body: io.NopCloser(strings.NewReader("data of " + url)), Request: req}, nil
+}
+
+func (f *fakeFetcher) count(url string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[url]
+}
+
+func imagePieces(urls ...string) []Piece {
+	var pieces []Piece
+	for _, url := range urls {
+		pieces = append(pieces, Piece{IMAGE, nil, map[string]string{"src": url, "alt": ""}})
+	}
+	return pieces
+}
+
+const (
+	img1 = "https://a.com/1.png"
+	img2 = "https://a.com/2.png"
+	img3 = "https://a.com/3.png"
+)
+
+func TestFetchImagesDedup(t *testing.T) {
+	fetcher := &fakeFetcher{}
+	pieces := []Piece{
+		imagePieces(img1)[0],
+		{BLOCK_QUOTES, imagePieces(img1, img2), nil},
+		{U_LIST, imagePieces(img2), nil},
+		{TABLE, Table{Rows: []TableRow{{{Content: imagePieces(img1), ColSpan: 1, RowSpan: 1}}}}, nil},
+	}
+	got, err := fetchImages(pieces, &Options{ImagePolicy: IMAGE_POLICY_SAVE, Fetcher: fetcher})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetcher.count(img1) != 1 || fetcher.count(img2) != 1 {
+		t.Errorf("requests = %v, want one per url", fetcher.requests)
+	}
+	images := collect(got, IMAGE)
+	want := []string{img1, img1, img2, img2, img1}
+	if len(images) != len(want) {
+		t.Fatalf("got %d images, want %d", len(images), len(want))
+	}
+	for i, piece := range images {
+		if data, _ := piece.Val.([]byte); string(data) != "data of "+want[i] {
+			t.Errorf("image %d = %q, want data of %s", i, data, want[i])
+		}
+	}
+}
+
+func TestFetchImagesWorkers(t *testing.T) {
+	fetcher := &fakeFetcher{delay: 20 * time.Millisecond}
+	var urls []string
+	for _, c := range "abcdefgh" {
+		urls = append(urls, "https://a.com/"+string(c)+".png")
+	}
+	if _, err := fetchImages(imagePieces(urls...), &Options{ImageWorkers: 3, Fetcher: fetcher}); err != nil {
+		t.Fatal(err)
+	}
+	if fetcher.peak > 3 {
+		t.Errorf("%d concurrent requests, want at most 3", fetcher.peak)
+	}
+	for _, url := range urls {
+		if fetcher.count(url) != 1 {
+			t.Errorf("%s fetched %d times", url, fetcher.count(url))
+		}
+	}
+}
+
+func TestFetchImagesRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		retries  int
+		requests int
+		ok       bool
+	}{
+		{"5xx then ok", []int{503}, 1, 2, true},
+		{"429 then ok", []int{429}, 1, 2, true},
+		{"5xx without retries", []int{502}, -1, 1, false},
+		{"5xx after retries", []int{500, 500}, 1, 2, false},
+		{"404 is not retried", []int{404}, 1, 1, false},
+	}
+	for _, tt := range tests {
+		fetcher := &fakeFetcher{statuses: map[string][]int{img1: tt.statuses}}
+		_, err := fetchImages(imagePieces(img1), &Options{ImageRetries: tt.retries, Fetcher: fetcher})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok = %v", tt.name, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrImageFetch) {
+			t.Errorf("%s: err = %v, want ErrImageFetch", tt.name, err)
+		}
+		if fetcher.count(img1) != tt.requests {
+			t.Errorf("%s: %d requests, want %d", tt.name, fetcher.count(img1), tt.requests)
+		}
+	}
+}
+
+func TestFetchImagesFirstError(t *testing.T) {
+	// 多张失败时报文中第一张
+	fetcher := &fakeFetcher{statuses: map[string][]int{img2: {404}, img3: {403}}}
+	_, err := fetchImages(imagePieces(img1, img2, img3), &Options{ImageWorkers: 1, ImageRetries: -1, Fetcher: fetcher})
+	if err == nil || !strings.Contains(err.Error(), img2) || !strings.Contains(err.Error(), "404") {
+		t.Errorf("err = %v, want the 404 of %s", err, img2)
+	}
+}
+
+func TestFetchImagesTimeout(t *testing.T) {
+	fetcher := &fakeFetcher{block: true}
+	start := time.Now()
+	_, err := fetchImages(imagePieces(img1), &Options{ImageRetries: -1, ImageTimeout: 20 * time.Millisecond, Fetcher: fetcher})
+	if !errors.Is(err, ErrImageFetch) {
+		t.Errorf("err = %v, want ErrImageFetch", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want the per-image timeout to apply", elapsed)
+	}
+}
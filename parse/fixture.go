@@ -0,0 +1,186 @@
+package parse
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// fixtureMeta 录制的响应，与响应体（.body 文件）放在同名的 .json 文件中
+type fixtureMeta struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Referer string      `json:"referer,omitempty"`
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+}
+
+// fixtureClientFile 录制目录中保存录制时 Client 设置的文件
+const fixtureClientFile = "client.json"
+
+// fixtureClient 录制时影响请求的 Client 设置，回放时按同样的设置发出请求才能对上录制的响应。
+// cookie、请求头等不保存，以免进入共享的回归用例
+type fixtureClient struct {
+	Referer string `json:"referer,omitempty"`
+}
+
+// 不录制的响应头：cookie 不应进入共享的回归用例，长度和压缩方式以录制下来的响应体为准
+var fixtureSkipHeaders = []string{"Set-Cookie", "Content-Length", "Content-Encoding"}
+
+// Recorder 把经过它的请求交给 next 发出，并把响应（文章页面和图片）录制到目录中，供 Replayer 回放
+type Recorder struct {
+	dir  string
+	next Fetcher
+}
+
+// NewRecorder 录制到 dir（不存在时创建），next 为 nil 时使用默认设置的 Client。
+// next 的 Referer 设置一起保存下来，回放时使用
+func NewRecorder(dir string, next Fetcher) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if next == nil {
+		next = (&Options{}).fetcher()
+	}
+	data, err := json.MarshalIndent(fixtureClient{clientOptions(next).Referer}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, fixtureClientFile), data); err != nil {
+		return nil, err
+	}
+	return &Recorder{dir: dir, next: next}, nil
+}
+
+// Do 发出请求并录制响应，请求失败（没有响应）时不录制
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	res, err := r.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	meta := fixtureMeta{req.Method, req.URL.String(), req.Header.Get("Referer"), res.StatusCode, res.Header.Clone()}
+	for _, name := range fixtureSkipHeaders {
+		meta.Header.Del(name)
+	}
+	if err := r.save(meta, body); err != nil {
+		return nil, fmt.Errorf("record %s: %w", meta.URL, err)
+	}
+	return res, nil
+}
+
+func (r *Recorder) save(meta fixtureMeta, body []byte) error {
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	base := fixturePath(r.dir, meta.Method, meta.URL, meta.Referer)
+	// 先写响应体再写 .json，回放时有 .json 就一定有完整的响应体
+	if err := writeFileAtomic(base+".body", body); err != nil {
+		return err
+	}
+	return writeFileAtomic(base+".json", metaData)
+}
+
+// Replayer 从 Recorder 录制的目录中回放响应，不访问网络；没有录制过的请求返回 ErrFixtureMissing
+type Replayer struct {
+	dir  string
+	opts ClientOptions // 录制时的设置
+}
+
+// NewReplayer 回放 dir 中录制的响应，按录制时的 Referer 设置下载图片
+func NewReplayer(dir string) (*Replayer, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	replayer := &Replayer{dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, fixtureClientFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		var client fixtureClient
+		if err := json.Unmarshal(data, &client); err != nil {
+			return nil, fmt.Errorf("read %s: %w", fixtureClientFile, err)
+		}
+		replayer.opts.Referer = client.Referer
+	}
+	return replayer, nil
+}
+
+// Do 返回录制的响应
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	referer := req.Header.Get("Referer")
+	base := fixturePath(r.dir, req.Method, url, referer)
+	metaData, err := os.ReadFile(base + ".json")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			if referer != "" {
+				return nil, fmt.Errorf("%w: %s %s (Referer: %s)", ErrFixtureMissing, req.Method, url, referer)
+			}
+			return nil, fmt.Errorf("%w: %s %s", ErrFixtureMissing, req.Method, url)
+		}
+		return nil, err
+	}
+	var meta fixtureMeta
+	if err := json.Unmarshal(metaData, &meta); err != nil {
+		return nil, fmt.Errorf("read fixture of %s: %w", url, err)
+	}
+	body, err := os.ReadFile(base + ".body")
+	if err != nil {
+		return nil, fmt.Errorf("read fixture of %s: %w", url, err)
+	}
+	header := meta.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        strconv.Itoa(meta.Status) + " " + http.StatusText(meta.Status),
+		StatusCode:    meta.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// fixturePath 录制文件的路径（不含扩展名），按请求方法、完整的 url 和 Referer 取哈希：
+// 防盗链时同一张图片换了 Referer 响应不同，要分开录制
+func fixturePath(dir string, method string, url string, referer string) string {
+	key := method + " " + url
+	if referer != "" {
+		key += " Referer: " + referer
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16]))
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,117 @@
+package parse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// testdata/replay 是一篇两张图都被防盗链替换的文章：带公众平台 Referer 时图片是同一张占位图，不带 Referer 时是原图
+func TestReplayArticle(t *testing.T) {
+	replayer, err := NewReplayer("testdata/replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	article, err := ParseFromURLWithOptions("https://mp.weixin.qq.com/s/replay-test", Options{ImagePolicy: IMAGE_POLICY_SAVE, Fetcher: replayer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := article.Title.Val.(string); title != "回放测试" {
+		t.Errorf("title = %q, want 回放测试", title)
+	}
+	images := collect(article.Content, IMAGE)
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2", len(images))
+	}
+	want := []map[string]string{
+		{"alt": "红", IMAGE_ATTR_TYPE: "image/png", IMAGE_ATTR_WIDTH: "3", IMAGE_ATTR_HEIGHT: "2"},
+		{"alt": "蓝", IMAGE_ATTR_TYPE: "image/png", IMAGE_ATTR_WIDTH: "2", IMAGE_ATTR_HEIGHT: "3"},
+	}
+	for i, image := range images {
+		if _, ok := image.Val.([]byte); !ok {
+			t.Errorf("image %d has no content", i)
+		}
+		checkAttrs(t, image.Attrs["alt"], image.Attrs, want[i])
+	}
+}
+
+// refererFetcher 按 Referer 返回不同内容的 Fetcher
+type refererFetcher struct{}
+
+func (refererFetcher) Do(req *http.Request) (*http.Response, error) {
+	body := "referer=" + req.Header.Get("Referer")
+	return &http.Response{StatusCode: 200, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestRecordKeepsRefererVariants(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, refererFetcher{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const url = "https://mmbiz.qpic.cn/mmbiz_png/x/640"
+	referers := []string{WechatReferer, "", "https://example.com/"}
+	for _, referer := range referers {
+		req, _ := newRequest(context.Background(), url, referer)
+		res, err := recorder.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	for _, referer := range referers {
+		req, _ := newRequest(context.Background(), url, referer)
+		res, err := replayer.Do(req)
+		if err != nil {
+			t.Fatalf("replay with Referer %q: %v", referer, err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != "referer="+referer {
+			t.Errorf("replay with Referer %q got %q", referer, body)
+		}
+	}
+	req, _ := newRequest(context.Background(), url, "https://other.example.com/")
+	if _, err := replayer.Do(req); !errors.Is(err, ErrFixtureMissing) {
+		t.Errorf("unrecorded Referer: err = %v, want ErrFixtureMissing", err)
+	}
+}
+
+// 录制时指定的 Referer 随录制保存，回放时不用再指定
+func TestReplayUsesRecordedReferer(t *testing.T) {
+	const page = "https://mp.weixin.qq.com/s/referer-none"
+	images := &fakeImages{images: map[string]string{
+		page: fmt.Sprintf(articlePage, `<p><img data-src="`+imgA+`" alt="图"/></p>`),
+		imgA: "a",
+	}}
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, newFakeClient(t, ClientOptions{Referer: "none"}, images))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{ImagePolicy: IMAGE_POLICY_SAVE, ImageRetries: -1, Fetcher: recorder}
+	if _, err := ParseFromURLWithOptions(page, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Fetcher = replayer
+	article, err := ParseFromURLWithOptions(page, opts)
+	if err != nil {
+		t.Fatalf("replay a recording made with Referer none: %v", err)
+	}
+	if got := fetchedData(t, collect(article.Content, IMAGE)); len(got) != 1 || got[0] != "a" {
+		t.Errorf("images = %v, want [a]", got)
+	}
+}
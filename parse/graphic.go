@@ -0,0 +1,146 @@
+package parse
+
+import (
+	"encoding/base64"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 公众号排版中不用 img 画出来的图：内嵌的 svg（互动模板、分隔线、图标等）和 CSS 的 background-image
+
+// background、background-image 中的 url()，style 的值可能是 data URI，含有分号，所以直接在整个 style 上匹配
+var backgroundURLReg = regexp.MustCompile(`(?i)background(?:-image)?\s*:[^;]*?url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"]*))\s*\)`)
+
+// backgroundImages 元素的背景图。公众号的背景图懒加载时真实地址在 data-lazy-bgimg 中，style 里是占位图
+func backgroundImages(s *goquery.Selection, opts *Options) []Piece {
+	if src := attrOf(s, "data-lazy-bgimg"); src != "" {
+		return []Piece{imageFromURL(src, opts)}
+	}
+	style, _ := s.Attr("style")
+	if !strings.Contains(style, "url(") {
+		return nil
+	}
+	var pieces []Piece
+	for _, m := range backgroundURLReg.FindAllStringSubmatch(style, -1) {
+		if src := strings.TrimSpace(m[1] + m[2] + m[3]); src != "" {
+			pieces = append(pieces, imageFromURL(src, opts))
+		}
+	}
+	return pieces
+}
+
+// imageFromURL 按地址生成图片 piece：data URI 直接取出内容，其它地址与 img 一样在正文解析完后统一下载
+func imageFromURL(src string, opts *Options) Piece {
+	if strings.HasPrefix(src, "data:") {
+		if data, ok := decodeDataURI(src); ok {
+			return embeddedImage(data, "", opts)
+		}
+	}
+	return Piece{IMAGE, nil, map[string]string{"src": absoluteURL(src), "alt": "", "title": ""}}
+}
+
+// decodeDataURI 取出 data URI 的内容，支持 base64 和百分号编码（svg 常用）两种写法
+func decodeDataURI(uri string) ([]byte, bool) {
+	head, data, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, false
+	}
+	if strings.HasSuffix(strings.ToLower(head), ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		return decoded, err == nil
+	}
+	decoded, err := url.PathUnescape(data)
+	return []byte(decoded), err == nil
+}
+
+// parseSVG 内嵌的 svg。互动模板等只是用 svg 来摆放图片的，输出其中的图片（image、foreignObject 里的 img 和背景图）；
+// 其它的 svg 整个作为 .svg 图片输出。不画任何东西的 svg（占位用的空 svg、只有 defs 的 MathJax 字形缓存）不输出
+func parseSVG(s *goquery.Selection, opts *Options) ([]Piece, error) {
+	images := backgroundImages(s, opts)
+	var err error
+	s.Find("*").EachWithBreak(func(i int, sc *goquery.Selection) bool {
+		switch {
+		case sc.Is("img"):
+			var image Piece
+			if image, err = parseImage(sc, opts); err != nil {
+				return false
+			}
+			images = append(images, image)
+		case sc.Is("image"):
+			// xlink:href 解析后属性名为 href；data URI 的图片是 svg 画面的一部分，留在 svg 里
+			if src := attrOf(sc, "href", "data-src"); src != "" && !strings.HasPrefix(src, "data:") {
+				images = append(images, imageFromURL(src, opts))
+			}
+		}
+		images = append(images, backgroundImages(sc, opts)...)
+		return true
+	})
+	if err != nil || len(images) > 0 {
+		return images, err
+	}
+	if s.ChildrenFiltered(":not(defs,title,desc,style,metadata)").Length() == 0 {
+		return nil, nil
+	}
+	alt := attrOf(s, "aria-label")
+	if alt == "" {
+		alt = strings.TrimSpace(s.ChildrenFiltered("title").First().Text())
+	}
+	return []Piece{svgImage(s, alt, opts)}, nil
+}
+
+// svgImage 把内嵌的 svg 转成图片，svg 本身不在网上，没有地址可以引用
+func svgImage(svg *goquery.Selection, alt string, opts *Options) Piece {
+	return embeddedImage(svgData(svg), alt, opts)
+}
+
+// embeddedImage 页面里直接带着内容的图片：SAVE 时作为文件保存，否则以 base64 内嵌
+func embeddedImage(data []byte, alt string, opts *Options) Piece {
+	attr := map[string]string{"src": "", "alt": alt, "title": ""}
+	setImageAttrs(attr, data)
+	if opts.ImagePolicy == IMAGE_POLICY_SAVE {
+		return Piece{IMAGE, data, attr}
+	}
+	return Piece{IMAGE_BASE64, img2base64(data), attr}
+}
+
+// svgData 输出成可以单独打开的 svg 文件：补上命名空间，
+// 并把引用的文档中其它位置的定义（如 MathJax 的全局字形缓存）复制到 defs 里
+func svgData(svg *goquery.Selection) []byte {
+	clone := svg.Clone()
+	if _, ok := clone.Attr("xmlns"); !ok {
+		clone.SetAttr("xmlns", "http://www.w3.org/2000/svg")
+	}
+	var defs []*goquery.Selection
+	root := svg.Parents().Last()
+	seen := make(map[string]bool)
+	svg.Find("use").Each(func(i int, use *goquery.Selection) {
+		// xlink:href 解析后属性名为 href
+		id := strings.TrimPrefix(attrOf(use, "href"), "#")
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		selector := `[id="` + strings.ReplaceAll(id, `"`, `\"`) + `"]`
+		if svg.Find(selector).Length() > 0 {
+			return
+		}
+		if def := root.Find(selector).First(); def.Length() > 0 {
+			defs = append(defs, def)
+		}
+	})
+	if len(defs) > 0 {
+		clone.AppendHtml("<defs></defs>")
+		target := clone.ChildrenFiltered("defs").Last()
+		for _, def := range defs {
+			target.AppendSelection(def.Clone())
+		}
+	}
+	html, _ := goquery.OuterHtml(clone)
+	if strings.Contains(html, "xlink:") && !strings.Contains(html, "xmlns:xlink") {
+		html = strings.Replace(html, "<svg", `<svg xmlns:xlink="http://www.w3.org/1999/xlink"`, 1)
+	}
+	return []byte(html)
+}
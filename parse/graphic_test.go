@@ -0,0 +1,135 @@
+package parse
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestBackgroundImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			"quoted background-image before content",
+			`<section style="background-image: url(&quot;https://mmbiz.qpic.cn/bg/0?wx_fmt=png&quot;); background-size: cover;"><p>文字</p></section>`,
+			"image(https://mmbiz.qpic.cn/bg/0?wx_fmt=png) text(文字)",
+		},
+		{
+			"background shorthand",
+			`<section style="background: #fff url('https://a.com/b.png') no-repeat center;"></section>`,
+			"image(https://a.com/b.png)",
+		},
+		{
+			"lazy background",
+			`<section data-lazy-bgimg="https://mmbiz.qpic.cn/real/0" style="background-image: url(https://res.wx.qq.com/loading.gif)"></section>`,
+			"image(https://mmbiz.qpic.cn/real/0)",
+		},
+		{
+			"protocol-relative url",
+			`<section style="background-image:url(//mmbiz.qpic.cn/c/0)"></section>`,
+			"image(https://mmbiz.qpic.cn/c/0)",
+		},
+		{
+			"inside an inline mark",
+			`<p><strong>粗<span style="background-image:url(https://a.com/i.png)">体</span></strong></p>`,
+			"bold(粗) image(https://a.com/i.png) bold(体)",
+		},
+		{
+			"no url",
+			`<section style="background-color: red; background-image: none;"><p>文字</p></section>`,
+			"text(文字)",
+		},
+	}
+	for _, tt := range tests {
+		if got := describe(parseContent(t, tt.content, Options{})); got != tt.want {
+			t.Errorf("%s:\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDataURIBackground(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString(encodeImage(t, "png", 2, 2))
+	content := `<section style="background-image: url(data:image/png;base64,` + png + `); background-size: 100%;"></section>` +
+		`<section style="background-image: url(&quot;data:image/svg+xml,%3Csvg xmlns='http://www.w3.org/2000/svg'%3E%3Crect/%3E%3C/svg%3E&quot;)"></section>`
+
+	images := collect(parseContent(t, content, Options{}), IMAGE_BASE64)
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2", len(images))
+	}
+	if images[0].Val != png {
+		t.Errorf("png background = %q, want the data URI content", images[0].Val)
+	}
+	checkAttrs(t, "png", images[0].Attrs, map[string]string{"src": "", IMAGE_ATTR_TYPE: "image/png"})
+	svg, _ := base64.StdEncoding.DecodeString(images[1].Val.(string))
+	if string(svg) != `<svg xmlns='http://www.w3.org/2000/svg'><rect/></svg>` {
+		t.Errorf("percent-encoded svg = %q", svg)
+	}
+
+	// SAVE 时直接带着内容，不需要下载
+	saved := collect(parseContent(t, content, Options{ImagePolicy: IMAGE_POLICY_SAVE, Fetcher: &fakeFetcher{block: true}}), IMAGE)
+	if len(saved) != 2 || string(saved[1].Val.([]byte)) != string(svg) {
+		t.Errorf("saved images = %d, want the decoded contents", len(saved))
+	}
+}
+
+func TestSVGImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			"image in document order",
+			`<p>前</p><svg viewBox="0 0 10 10"><image xlink:href="https://mmbiz.qpic.cn/a/0" width="10"></image></svg><p>后</p>`,
+			"text(前) image(https://mmbiz.qpic.cn/a/0) text(后)",
+		},
+		{
+			"tap to reveal",
+			`<svg style="background-image: url(https://mmbiz.qpic.cn/front/0)" viewBox="0 0 1 1"><g><foreignObject><section style="background-image: url(https://mmbiz.qpic.cn/back/0)"></section></foreignObject>` +
+				`<animate attributeName="opacity" begin="click" to="0"></animate></g></svg>`,
+			"image(https://mmbiz.qpic.cn/front/0) image(https://mmbiz.qpic.cn/back/0)",
+		},
+		{
+			"img in foreignObject",
+			`<svg><foreignObject><img data-src="https://mmbiz.qpic.cn/f/0"/></foreignObject><image href="https://mmbiz.qpic.cn/g/0"></image></svg>`,
+			"image(https://mmbiz.qpic.cn/f/0) image(https://mmbiz.qpic.cn/g/0)",
+		},
+		{"empty placeholder", `<p>前</p><svg viewBox="0 0 1 1"></svg><p>后</p>`, "text(前) text(后)"},
+		{"only defs", `<svg style="display:none"><defs><path id="p" d="M0 0"></path></defs></svg><p>后</p>`, "text(后)"},
+	}
+	for _, tt := range tests {
+		if got := describe(parseContent(t, tt.content, Options{})); got != tt.want {
+			t.Errorf("%s:\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSVGSavedWhole(t *testing.T) {
+	// 不含外部图片的 svg（分隔线、图标，以及 data URI 的 image）整个作为 .svg 图片
+	tests := []struct {
+		name    string
+		content string
+		alt     string
+		keep    string
+	}{
+		{"drawing", `<svg viewBox="0 0 100 2"><title>分隔线</title><rect width="100" height="2" fill="#ccc"></rect></svg>`, "分隔线", `<rect width="100" height="2" fill="#ccc">`},
+		{"aria label", `<svg aria-label="图标" viewBox="0 0 1 1"><circle r="1"></circle></svg>`, "图标", `<circle r="1">`},
+		{"data URI image", `<svg viewBox="0 0 1 1"><image href="data:image/png;base64,AAAA"></image></svg>`, "", `href="data:image/png;base64,AAAA"`},
+	}
+	for _, tt := range tests {
+		pieces := parseContent(t, tt.content, Options{ImagePolicy: IMAGE_POLICY_SAVE})
+		images := collect(pieces, IMAGE)
+		if len(images) != 1 {
+			t.Errorf("%s: got %d images, want 1", tt.name, len(images))
+			continue
+		}
+		checkAttrs(t, tt.name, images[0].Attrs, map[string]string{"alt": tt.alt, IMAGE_ATTR_TYPE: "image/svg+xml"})
+		data, _ := images[0].Val.([]byte)
+		if svg := string(data); !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `xmlns="http://www.w3.org/2000/svg"`) || !strings.Contains(svg, tt.keep) {
+			t.Errorf("%s: svg = %s", tt.name, svg)
+		}
+	}
+}
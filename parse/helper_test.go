@@ -0,0 +1,106 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// 最小的文章页面，%s 处放正文
+const articlePage = `<html><head></head><body><div id="img-content"><h1 id="activity-name">标题</h1><div id="js_content">%s</div></div></body></html>`
+
+// parseContent 把 content 放进最小的文章页面里按 opts 解析，返回正文
+func parseContent(t *testing.T, content string, opts Options) []Piece {
+	t.Helper()
+	article, err := ParseFromHTMLStringWithOptions(fmt.Sprintf(articlePage, content), opts)
+	if err != nil {
+		t.Fatalf("parse %q: %v", content, err)
+	}
+	return article.Content
+}
+
+// collect 按文中的顺序取出所有 typ 类型的 piece，包括引用、列表和表格里的
+func collect(pieces []Piece, typ PieceType) []Piece {
+	var res []Piece
+	for _, piece := range pieces {
+		if piece.Type == typ {
+			res = append(res, piece)
+		}
+		switch val := piece.Val.(type) {
+		case []Piece:
+			res = append(res, collect(val, typ)...)
+		case Table:
+			for _, row := range val.Rows {
+				for _, cell := range row {
+					res = append(res, collect(cell.Content, typ)...)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// checkAttrs attrs 中是否有 want 的全部键值
+func checkAttrs(t *testing.T, name string, attrs map[string]string, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: attr %s = %q, want %q", name, k, attrs[k], v)
+		}
+	}
+}
+
+// 测试中 piece 类型的简写
+var pieceNames = map[PieceType]string{
+	HEADER: "h", LINK: "link", NORMAL_TEXT: "text", BOLD_TEXT: "bold", ITALIC_TEXT: "italic",
+	BOLD_ITALIC_TEXT: "bolditalic", IMAGE: "image", IMAGE_BASE64: "base64", TABLE: "table",
+	CODE_INLINE: "code", CODE_BLOCK: "pre", BLOCK_QUOTES: "quote", O_LIST: "ol", U_LIST: "ul",
+	HR: "hr", BR: "br", STRIKETHROUGH_TEXT: "strike", UNDERLINE_TEXT: "underline", SUP_TEXT: "sup",
+	SUB_TEXT: "sub", VIDEO: "video", AUDIO: "audio", EMBED_CARD: "card", HIGHLIGHT_TEXT: "highlight",
+	MATH: "math", NULL: "null",
+}
+
+// describe 把 pieces 写成便于比较的一行，忽略换行和空白的文字：
+// 文字为 bold(粗)，叠加多个修饰时带上 marks，如 code{bold,code}(x)；列表、引用为 ul[...]
+func describe(pieces []Piece) string {
+	var parts []string
+	for _, piece := range pieces {
+		name := pieceNames[piece.Type]
+		switch val := piece.Val.(type) {
+		case []Piece:
+			if piece.Type == O_LIST {
+				name += piece.Attrs["number"]
+			}
+			parts = append(parts, name+"["+describe(val)+"]")
+		case string:
+			if piece.Type == BR || piece.Type == NORMAL_TEXT && strings.TrimSpace(val) == "" {
+				continue
+			}
+			switch piece.Type {
+			case HEADER:
+				name += piece.Attrs["level"]
+			case LINK:
+				val += "->" + piece.Attrs["href"]
+			case MATH:
+				if piece.Attrs["display"] == "true" {
+					name = "mathblock"
+				}
+			}
+			if marks := piece.Attrs["marks"]; strings.Contains(marks, ",") {
+				name += "{" + marks + "}"
+			}
+			parts = append(parts, name+"("+val+")")
+		case []string:
+			parts = append(parts, name+":"+piece.Attrs["lang"]+"("+strings.Join(val, "\\n")+")")
+		default:
+			if piece.Type == BR {
+				continue
+			}
+			if piece.Type == IMAGE || piece.Type == IMAGE_BASE64 {
+				name += "(" + piece.Attrs["src"] + ")"
+			}
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,139 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 图片 piece 上记录的类型和像素大小，取自下载到的图片内容
+const (
+	IMAGE_ATTR_TYPE   = "type"   // MIME 类型，如 image/jpeg
+	IMAGE_ATTR_WIDTH  = "width"  // 像素宽度
+	IMAGE_ATTR_HEIGHT = "height" // 像素高度
+)
+
+// 文件头魔数 => MIME 类型
+var imageMagics = []struct {
+	offset int
+	magic  string
+	mime   string
+}{
+	{0, "\x89PNG\r\n\x1a\n", "image/png"},
+	{0, "\xff\xd8\xff", "image/jpeg"},
+	{0, "GIF87a", "image/gif"},
+	{0, "GIF89a", "image/gif"},
+	{8, "WEBP", "image/webp"}, // RIFF????WEBP
+	{0, "BM", "image/bmp"},
+	{0, "\x00\x00\x01\x00", "image/x-icon"},
+	{0, "II*\x00", "image/tiff"},
+	{0, "MM\x00*", "image/tiff"},
+	{4, "ftypavif", "image/avif"},
+	{4, "ftypavis", "image/avif"},
+	{4, "ftypheic", "image/heic"},
+	{4, "ftypheix", "image/heic"},
+	{4, "ftypmif1", "image/heif"},
+}
+
+var svgReg = regexp.MustCompile(`(?is)^\s*(<\?xml[^>]*>\s*)?(<!--.*?-->\s*)*(<!doctype svg[^>]*>\s*)?<svg[\s>]`)
+
+// DetectImageType 按文件头的魔数判断图片类型，返回 MIME 类型，判断不出时返回空字符串
+func DetectImageType(data []byte) string {
+	for _, m := range imageMagics {
+		if len(data) >= m.offset+len(m.magic) && string(data[m.offset:m.offset+len(m.magic)]) == m.magic {
+			if m.mime == "image/webp" && !bytes.HasPrefix(data, []byte("RIFF")) {
+				continue
+			}
+			return m.mime
+		}
+	}
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if svgReg.Match(head) {
+		return "image/svg+xml"
+	}
+	return ""
+}
+
+// ImageTypeExt 图片 MIME 类型对应的扩展名，未知类型返回空字符串
+func ImageTypeExt(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return "jpg"
+	case "image/svg+xml":
+		return "svg"
+	case "image/x-icon":
+		return "ico"
+	case "image/png", "image/gif", "image/webp", "image/bmp", "image/tiff", "image/avif", "image/heic", "image/heif":
+		return strings.TrimPrefix(mimeType, "image/")
+	}
+	return ""
+}
+
+// ImageSize 图片的像素宽高，判断不出时返回 0, 0
+func ImageSize(data []byte) (int, int) {
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		return config.Width, config.Height
+	}
+	switch DetectImageType(data) {
+	case "image/webp":
+		return webpSize(data)
+	case "image/bmp":
+		if len(data) >= 26 {
+			w := int32(binary.LittleEndian.Uint32(data[18:22]))
+			h := int32(binary.LittleEndian.Uint32(data[22:26]))
+			if h < 0 {
+				// 高度为负表示自上而下存储
+				h = -h
+			}
+			return int(w), int(h)
+		}
+	}
+	return 0, 0
+}
+
+// webpSize 标准库没有 webp 解码器，直接读 VP8 / VP8L / VP8X 块头里的宽高
+func webpSize(data []byte) (int, int) {
+	if len(data) < 30 {
+		return 0, 0
+	}
+	chunk := data[12:]
+	switch string(chunk[:4]) {
+	case "VP8 ":
+		// 关键帧起始码 9d 01 2a 之后是 14 位的宽高
+		if chunk[11] == 0x9d && chunk[12] == 0x01 && chunk[13] == 0x2a {
+			return int(binary.LittleEndian.Uint16(chunk[14:16]) & 0x3fff), int(binary.LittleEndian.Uint16(chunk[16:18]) & 0x3fff)
+		}
+	case "VP8L":
+		// 签名 0x2f 之后是各 14 位的 宽-1、高-1
+		if chunk[8] == 0x2f {
+			bits := binary.LittleEndian.Uint32(chunk[9:13])
+			return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1
+		}
+	case "VP8X":
+		// 各 24 位的 宽-1、高-1
+		w := int(chunk[12]) | int(chunk[13])<<8 | int(chunk[14])<<16
+		h := int(chunk[15]) | int(chunk[16])<<8 | int(chunk[17])<<16
+		return w + 1, h + 1
+	}
+	return 0, 0
+}
+
+// setImageAttrs 在图片 piece 的属性上记录图片的类型和像素大小
+func setImageAttrs(attr map[string]string, data []byte) {
+	if mimeType := DetectImageType(data); mimeType != "" {
+		attr[IMAGE_ATTR_TYPE] = mimeType
+	}
+	if w, h := ImageSize(data); w > 0 && h > 0 {
+		attr[IMAGE_ATTR_WIDTH] = strconv.Itoa(w)
+		attr[IMAGE_ATTR_HEIGHT] = strconv.Itoa(h)
+	}
+}
@@ -0,0 +1,179 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// encodeImage 用标准库编码一张 w x h 的图片
+func encodeImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// webpImage 只有文件头和第一个块头的 webp，足够判断类型和宽高
+func webpImage(chunk string, w, h int) []byte {
+	body := make([]byte, 18)
+	switch chunk {
+	case "VP8L":
+		body[0] = 0x2f
+		binary.LittleEndian.PutUint32(body[1:5], uint32(w-1)|uint32(h-1)<<14)
+	case "VP8X":
+		body[4], body[5], body[6] = byte(w-1), byte((w-1)>>8), byte((w-1)>>16)
+		body[7], body[8], body[9] = byte(h-1), byte((h-1)>>8), byte((h-1)>>16)
+	case "VP8 ":
+		body[3], body[4], body[5] = 0x9d, 0x01, 0x2a
+		binary.LittleEndian.PutUint16(body[6:8], uint16(w))
+		binary.LittleEndian.PutUint16(body[8:10], uint16(h))
+	}
+	data := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk + "\x00\x00\x00\x00")
+	return append(data, body...)
+}
+
+// bmpImage 只有文件头和信息头的 bmp，h 为负表示自上而下存储
+func bmpImage(w, h int32) []byte {
+	data := make([]byte, 54)
+	copy(data, "BM")
+	binary.LittleEndian.PutUint32(data[18:22], uint32(w))
+	binary.LittleEndian.PutUint32(data[22:26], uint32(h))
+	return data
+}
+
+func TestDetectImageType(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", encodeImage(t, "png", 1, 1), "image/png"},
+		{"jpeg", encodeImage(t, "jpeg", 1, 1), "image/jpeg"},
+		{"gif", encodeImage(t, "gif", 1, 1), "image/gif"},
+		{"webp", webpImage("VP8L", 1, 1), "image/webp"},
+		{"bmp", bmpImage(1, 1), "image/bmp"},
+		{"tiff", []byte("II*\x00\x08\x00\x00\x00"), "image/tiff"},
+		{"avif", []byte("\x00\x00\x00\x1cftypavif"), "image/avif"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic"), "image/heic"},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml"},
+		{"svg with prolog", []byte("<?xml version=\"1.0\"?>\n<!-- logo -->\n<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"\">\n<SVG width=\"1\">"), "image/svg+xml"},
+		{"riff but not webp", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), ""},
+		{"WEBP without riff", []byte("XXXX\x00\x00\x00\x00WEBPVP8L"), ""},
+		{"html", []byte("<html><body><svg></svg></body></html>"), ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := DetectImageType(tt.data); got != tt.want {
+			t.Errorf("%s: DetectImageType = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImageSize(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		w, h int
+	}{
+		{"png", encodeImage(t, "png", 4, 3), 4, 3},
+		{"jpeg", encodeImage(t, "jpeg", 16, 9), 16, 9},
+		{"gif", encodeImage(t, "gif", 2, 7), 2, 7},
+		{"webp lossless", webpImage("VP8L", 640, 480), 640, 480},
+		{"webp extended", webpImage("VP8X", 1080, 20000), 1080, 20000},
+		{"webp lossy", webpImage("VP8 ", 300, 200), 300, 200},
+		{"bmp", bmpImage(5, 6), 5, 6},
+		{"bmp top-down", bmpImage(5, -6), 5, 6},
+		{"svg", []byte(`<svg width="10" height="10"></svg>`), 0, 0},
+		{"truncated webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8L"), 0, 0},
+		{"garbage", []byte("not an image"), 0, 0},
+	}
+	for _, tt := range tests {
+		if w, h := ImageSize(tt.data); w != tt.w || h != tt.h {
+			t.Errorf("%s: ImageSize = %dx%d, want %dx%d", tt.name, w, h, tt.w, tt.h)
+		}
+	}
+}
+
+func TestImageTypeExt(t *testing.T) {
+	tests := map[string]string{
+		"image/png":     "png",
+		"image/jpeg":    "jpg",
+		"image/gif":     "gif",
+		"image/webp":    "webp",
+		"image/svg+xml": "svg",
+		"image/x-icon":  "ico",
+		"image/heic":    "heic",
+		"text/html":     "",
+		"":              "",
+	}
+	for mimeType, want := range tests {
+		if got := ImageTypeExt(mimeType); got != want {
+			t.Errorf("ImageTypeExt(%q) = %q, want %q", mimeType, got, want)
+		}
+	}
+}
+
+func TestSetImageAttrs(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+	}{
+		{"webp", webpImage("VP8X", 750, 1334), map[string]string{IMAGE_ATTR_TYPE: "image/webp", IMAGE_ATTR_WIDTH: "750", IMAGE_ATTR_HEIGHT: "1334"}},
+		{"png", encodeImage(t, "png", 4, 3), map[string]string{IMAGE_ATTR_TYPE: "image/png", IMAGE_ATTR_WIDTH: "4", IMAGE_ATTR_HEIGHT: "3"}},
+		{"unknown", []byte("not an image"), map[string]string{IMAGE_ATTR_TYPE: "", IMAGE_ATTR_WIDTH: "", IMAGE_ATTR_HEIGHT: ""}},
+	}
+	for _, tt := range tests {
+		attr := map[string]string{"src": "https://mmbiz.qpic.cn/a"}
+		setImageAttrs(attr, tt.data)
+		checkAttrs(t, tt.name, attr, tt.want)
+	}
+}
+
+func TestDataURIImageAttrs(t *testing.T) {
+	webp := base64.StdEncoding.EncodeToString(webpImage("VP8X", 750, 1334))
+	png := base64.StdEncoding.EncodeToString(encodeImage(t, "png", 4, 3))
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{
+			"webp background",
+			fmt.Sprintf(`<section style="background-image: url(data:image/png;base64,%s)"></section>`, webp),
+			map[string]string{IMAGE_ATTR_TYPE: "image/webp", IMAGE_ATTR_WIDTH: "750", IMAGE_ATTR_HEIGHT: "1334"},
+		},
+		{
+			"png background",
+			fmt.Sprintf(`<section style="background-image: url('data:image/jpeg;base64,%s')"></section>`, png),
+			map[string]string{IMAGE_ATTR_TYPE: "image/png", IMAGE_ATTR_WIDTH: "4", IMAGE_ATTR_HEIGHT: "3"},
+		},
+	}
+	for _, tt := range tests {
+		images := collect(parseContent(t, tt.content, Options{}), IMAGE_BASE64)
+		if len(images) != 1 {
+			t.Errorf("%s: got %d images, want 1", tt.name, len(images))
+			continue
+		}
+		checkAttrs(t, tt.name, images[0].Attrs, tt.want)
+	}
+}
@@ -0,0 +1,31 @@
+package parse
+
+import "testing"
+
+func TestInlineTags(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{`<p>a<strong>粗</strong>b</p>`, "text(a) bold(粗) text(b)"},
+		{`<p><b>粗</b><em>斜</em><i>斜2</i></p>`, "bold(粗) italic(斜斜2)"},
+		{`<p><strong><em>粗斜</em></strong></p>`, "bolditalic{bold,italic}(粗斜)"},
+		{`<p><del>删</del><s>除</s><strike>线</strike></p>`, "strike(删除线)"},
+		{`<p><u>下</u><ins>划线</ins></p>`, "underline(下划线)"},
+		{`<p><mark>高亮</mark></p>`, "highlight(高亮)"},
+		{`<p>x<sup>2</sup>，H<sub>2</sub>O</p>`, "text(x) sup(2) text(，H) sub(2) text(O)"},
+		{`<p><code>go</code> <kbd>Ctrl</kbd></p>`, "code(go) code(Ctrl)"},
+		{`<p><strong><code>x</code></strong></p>`, "code{bold,code}(x)"},
+		{`<p><strong>a</strong><strong>b</strong></p>`, "bold(ab)"},
+		{`<p><strong>a</strong><em>b</em></p>`, "bold(a) italic(b)"},
+		{`<p><strong>粗<a href="https://a.com/">链接</a></strong></p>`, "bold(粗) link(链接->https://a.com/)"},
+		{`<p><em>上<br>下</em></p>`, "italic(上) italic(下)"},
+		{`<p><span><strong>听<mpvoice name="录音" voice_encode_fileid="a"></mpvoice></strong></span></p>`, "bold(听) audio"},
+		{`<p><strong>看<iframe data-src="https://v.qq.com/txp/iframe/player.html?vid=abc"></iframe></strong></p>`, "bold(看) video"},
+	}
+	for _, tt := range tests {
+		if got := describe(parseContent(t, tt.html, Options{})); got != tt.want {
+			t.Errorf("%s\n got: %s\nwant: %s", tt.html, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package parse
+
+import "testing"
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"unordered", `<ul><li>a</li><li>b</li></ul>`, "ul[text(a)] ul[text(b)]"},
+		{"ordered", `<ol><li>a</li><li>b</li></ol>`, "ol1[text(a)] ol2[text(b)]"},
+		{"start", `<ol start="3"><li>a</li><li>b</li></ol>`, "ol3[text(a)] ol4[text(b)]"},
+		{"value", `<ol><li>a</li><li value="7">b</li><li>c</li></ol>`, "ol1[text(a)] ol7[text(b)] ol8[text(c)]"},
+		{"nested", `<ul><li>a<ul><li>a1</li><li>a2</li></ul></li><li>b</li></ul>`, "ul[text(a) ul[text(a1)] ul[text(a2)]] ul[text(b)]"},
+		{"nested ordered", `<ol><li>a<ol><li>x</li></ol></li></ol>`, "ol1[text(a) ol1[text(x)]]"},
+		{"sublist outside li", `<ul><li>a</li><ul><li>a1</li></ul><li>b</li></ul>`, "ul[text(a) ul[text(a1)]] ul[text(b)]"},
+		{"paragraphs in item", `<ul><li><p>第一段</p><p>第二段</p></li></ul>`, "ul[text(第一段) text(第二段)]"},
+		{"inline marks in item", `<ol><li><strong>粗</strong>文字</li></ol>`, "ol1[bold(粗) text(文字)]"},
+	}
+	for _, tt := range tests {
+		if got := describe(parseContent(t, tt.html, Options{})); got != tt.want {
+			t.Errorf("%s\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package parse
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 公式 MATH 的 Val 为 TeX 源码，Attrs：display 为 "true" 时是独占一行的公式，否则是行内公式
+
+// 记录公式 TeX 源码的属性，公众号编辑器的公式用 data-formula，其它排版工具各有各的写法
+var mathSourceAttrs = []string{"data-formula", "data-latex", "data-tex"}
+
+// isMath 是否为公式：带 TeX 源码属性的元素（公众号编辑器、mdnice 等插入的 svg 公式）、mdnice 包在公式外面的
+// span-inline-equation 和 block-equation，或 MathJax 3 的 mjx-container
+func isMath(s *goquery.Selection) bool {
+	return s.Is("mjx-container,[data-formula],[data-formula-type],[data-latex],[data-tex],.span-inline-equation,.block-equation")
+}
+
+// parseMath 取出公式的 TeX 源码；找不到源码时退回到公式的图片：img 照常下载，内嵌的 svg 按 ImagePolicy 保存或内嵌
+func parseMath(s *goquery.Selection, opts *Options) ([]Piece, error) {
+	if tex := mathSource(s); tex != "" {
+		var attr map[string]string
+		if isDisplayMath(s) {
+			attr = map[string]string{"display": "true"}
+		}
+		return []Piece{{MATH, tex, attr}}, nil
+	}
+	if img := s.Filter("img").AddSelection(s.Find("img")).First(); img.Length() > 0 {
+		image, err := parseImage(img, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []Piece{image}, nil
+	}
+	if svg := s.Filter("svg").AddSelection(s.Find("svg")).First(); svg.Length() > 0 {
+		return []Piece{svgImage(svg, attrOf(s, "aria-label", "title"), opts)}, nil
+	}
+	return nil, nil
+}
+
+// mathSource 公式的 TeX 源码：先看元素自身和里面的 svg、img 上的属性，
+// 再看 MathJax 辅助 MathML 中的 TeX 注解，都没有时返回空字符串
+func mathSource(s *goquery.Selection) string {
+	if tex := attrOf(s, mathSourceAttrs...); tex != "" {
+		return tex
+	}
+	var tex string
+	s.Find("[data-formula],[data-latex],[data-tex]").EachWithBreak(func(i int, sc *goquery.Selection) bool {
+		tex = attrOf(sc, mathSourceAttrs...)
+		return tex == ""
+	})
+	if tex != "" {
+		return tex
+	}
+	return strings.TrimSpace(s.Find(`annotation[encoding="application/x-tex"]`).First().Text())
+}
+
+// isDisplayMath 是否为独占一行的公式：公众号编辑器的 data-formula-type="block-equation"、
+// mdnice 的 block-equation，或 MathJax 的 display="true"
+func isDisplayMath(s *goquery.Selection) bool {
+	block := s.Closest(`[data-formula-type],mjx-container[display],.block-equation,.span-inline-equation`)
+	if block.Length() == 0 {
+		return false
+	}
+	if formulaType, ok := block.Attr("data-formula-type"); ok {
+		return strings.Contains(formulaType, "block")
+	}
+	if display, ok := block.Attr("display"); ok {
+		return display == "true" || display == "block"
+	}
+	return block.HasClass("block-equation")
+}
@@ -0,0 +1,107 @@
+package parse
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestParseMath(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			"inline data-formula",
+			`<p>设<span class="span-inline-equation"><svg data-formula="x^2" style="vertical-align:-0.1ex"><g></g></svg></span>为正</p>`,
+			"text(设) math(x^2) text(为正)",
+		},
+		{
+			"inline equation type",
+			`<p><span data-formula-type="inline-equation"><svg data-formula="a+b"></svg></span></p>`,
+			"math(a+b)",
+		},
+		{
+			"block equation",
+			`<section data-formula-type="block-equation" style="text-align:center"><svg data-formula="\sum_{i=1}^n a_i"><g></g></svg></section>`,
+			`mathblock(\sum_{i=1}^n a_i)`,
+		},
+		{
+			"mdnice block",
+			`<section class="block-equation"><svg data-tex="E=mc^2"></svg></section>`,
+			"mathblock(E=mc^2)",
+		},
+		{
+			"latex on img",
+			`<p>面积 <img data-latex="\pi r^2" src="https://a.com/f.svg"/></p>`,
+			`text(面积 ) math(\pi r^2)`,
+		},
+		{
+			"mathjax annotation",
+			`<p><mjx-container class="MathJax" jax="SVG"><svg><g></g></svg><mjx-assistive-mml><math><semantics><mi>y</mi>` +
+				`<annotation encoding="application/x-tex">y = kx</annotation></semantics></math></mjx-assistive-mml></mjx-container></p>`,
+			"math(y = kx)",
+		},
+		{
+			"mathjax display",
+			`<mjx-container class="MathJax" jax="SVG" display="true"><svg><g></g></svg><mjx-assistive-mml display="block"><math><semantics>` +
+				`<annotation encoding="application/x-tex">\int_0^1 f(x)\,dx</annotation></semantics></math></mjx-assistive-mml></mjx-container>`,
+			`mathblock(\int_0^1 f(x)\,dx)`,
+		},
+		{
+			"img without source",
+			`<p><span data-formula-type="inline-equation"><img data-src="https://a.com/formula.png"/></span></p>`,
+			"image(https://a.com/formula.png)",
+		},
+	}
+	for _, tt := range tests {
+		if got := describe(parseContent(t, tt.content, Options{})); got != tt.want {
+			t.Errorf("%s:\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMathSVGFallback(t *testing.T) {
+	// MathJax 的字形放在页面上的全局缓存里，输出的 svg 要带上用到的定义
+	content := `<svg style="display:none"><defs><path id="MJX-1-TEX-I-78" d="M52 289"></path><path id="MJX-1-TEX-N-32" d="M109 429"></path></defs></svg>` +
+		`<p><mjx-container class="MathJax" jax="SVG" aria-label="x"><svg viewBox="0 0 10 10"><g><use xlink:href="#MJX-1-TEX-I-78"></use></g></svg></mjx-container></p>`
+	images := collect(parseContent(t, content, Options{}), IMAGE_BASE64)
+	if len(images) != 1 {
+		t.Fatalf("got %d images, want 1", len(images))
+	}
+	checkAttrs(t, "svg", images[0].Attrs, map[string]string{"alt": "x", IMAGE_ATTR_TYPE: "image/svg+xml"})
+	data, err := base64.StdEncoding.DecodeString(images[0].Val.(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := string(data)
+	for _, want := range []string{`xmlns="http://www.w3.org/2000/svg"`, `xmlns:xlink="http://www.w3.org/1999/xlink"`, `<defs><path id="MJX-1-TEX-I-78"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("svg missing %s:\n%s", want, svg)
+		}
+	}
+	if strings.Contains(svg, "MJX-1-TEX-N-32") {
+		t.Errorf("svg copies unused definitions:\n%s", svg)
+	}
+
+	// SAVE 时作为 .svg 文件
+	images = collect(parseContent(t, content, Options{ImagePolicy: IMAGE_POLICY_SAVE}), IMAGE)
+	if len(images) != 1 || string(images[0].Val.([]byte)) != svg {
+		t.Errorf("saved svg differs from the embedded one")
+	}
+}
+
+func TestInlineMathKeepsLine(t *testing.T) {
+	// mdnice 包在行内公式外的 span 不能让句子断行
+	pieces := parseContent(t, `<p>设<span class="span-inline-equation"><svg data-formula="x^2"><g></g></svg></span>为正</p>`, Options{})
+	var types []PieceType
+	for _, piece := range pieces {
+		if piece.Type != BR || len(types) > 0 {
+			types = append(types, piece.Type)
+		}
+	}
+	if len(types) < 3 || types[0] != NORMAL_TEXT || types[1] != MATH || types[2] != NORMAL_TEXT {
+		t.Errorf("pieces = %v, want text, math, text on one line", types)
+	}
+}
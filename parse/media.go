@@ -0,0 +1,165 @@
+package parse
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 视频、音频、卡片类 Piece 的 Attrs：
+//   - VIDEO:      provider(mpvideo/tencent/channels)、src 播放页地址、embed 可嵌入的播放器地址、file 视频文件地址、poster 封面、title、duration（秒）、
+//     width、height（视频的像素宽高）
+//   - AUDIO:      provider(mpvoice/mpaudio/qqmusic)、src 音频文件地址、poster 封面、title、author、duration（秒）
+//   - EMBED_CARD: kind(miniprogram/profile/iframe)、title、desc、image、url、appid、path
+
+// isMedia 是否为视频、音频、小程序、名片等嵌入内容
+func isMedia(s *goquery.Selection) bool {
+	return s.Is("iframe,mpvoice,mp-common-mpaudio,qqmusic,mp-common-videosnap,mp-miniprogram,mp-common-profile")
+}
+
+func parseMedia(s *goquery.Selection) []Piece {
+	attr := make(map[string]string)
+	switch {
+	case s.Is("iframe"):
+		src := attrOf(s, "data-src", "src")
+		if src == "" {
+			return nil
+		}
+		src = absoluteURL(src)
+		if strings.Contains(src, "v.qq.com") {
+			// 腾讯视频
+			attr["provider"] = "tencent"
+			if vid := queryValue(src, "vid"); vid != "" {
+				attr["src"] = "https://v.qq.com/x/page/" + vid + ".html"
+				attr["embed"] = "https://v.qq.com/txp/iframe/player.html?vid=" + vid
+			} else {
+				attr["src"] = src
+				attr["embed"] = src
+			}
+			return []Piece{{VIDEO, nil, attr}}
+		}
+		if vid, _ := s.Attr("data-mpvid"); vid != "" || strings.Contains(src, "action=mpvideo") {
+			// 公众号视频
+			attr["provider"] = "mpvideo"
+			attr["src"] = src
+			attr["poster"] = unescapeURL(attrOf(s, "data-cover"))
+			attr["title"] = attrOf(s, "data-title", "title", "data-name")
+			attr["duration"] = firstNonEmpty(secondsOf(attrOf(s, "data-duration")), millisToSeconds(attrOf(s, "data-play_length")))
+			attr["width"] = attrOf(s, "data-vw")
+			attr["height"] = attrOf(s, "data-vh")
+			return []Piece{{VIDEO, nil, attr}}
+		}
+		attr["kind"] = "iframe"
+		attr["url"] = src
+		return []Piece{{EMBED_CARD, nil, attr}}
+	case s.Is("mp-common-videosnap"):
+		// 视频号视频，无法直接链接到视频本身
+		attr["provider"] = "channels"
+		attr["poster"] = attrOf(s, "data-url")
+		attr["title"] = attrOf(s, "data-desc", "data-nickname")
+		return []Piece{{VIDEO, nil, attr}}
+	case s.Is("mpvoice,mp-common-mpaudio"):
+		attr["provider"] = "mpvoice"
+		if s.Is("mp-common-mpaudio") {
+			attr["provider"] = "mpaudio"
+		}
+		if fileID := attrOf(s, "voice_encode_fileid", "data-voice_encode_fileid"); fileID != "" {
+			attr["src"] = "https://res.wx.qq.com/voice/getvoice?mediaid=" + fileID
+		}
+		attr["title"] = attrOf(s, "name", "data-name")
+		attr["author"] = attrOf(s, "author", "data-author")
+		attr["poster"] = attrOf(s, "cover", "data-cover")
+		attr["duration"] = millisToSeconds(attrOf(s, "play_length", "data-play_length"))
+		return []Piece{{AUDIO, nil, attr}}
+	case s.Is("qqmusic"):
+		attr["provider"] = "qqmusic"
+		attr["src"] = attrOf(s, "audiourl")
+		attr["title"] = attrOf(s, "music_name")
+		attr["author"] = attrOf(s, "singer")
+		attr["poster"] = attrOf(s, "albumurl")
+		attr["duration"] = millisToSeconds(attrOf(s, "play_length"))
+		return []Piece{{AUDIO, nil, attr}}
+	case s.Is("mp-miniprogram"):
+		attr["kind"] = "miniprogram"
+		attr["title"] = attrOf(s, "data-miniprogram-title")
+		attr["desc"] = attrOf(s, "data-miniprogram-nickname")
+		attr["image"] = attrOf(s, "data-miniprogram-imageurl")
+		attr["appid"] = attrOf(s, "data-miniprogram-appid")
+		attr["path"] = attrOf(s, "data-miniprogram-path")
+		return []Piece{{EMBED_CARD, nil, attr}}
+	case s.Is("mp-common-profile"):
+		attr["kind"] = "profile"
+		attr["title"] = attrOf(s, "data-nickname")
+		attr["desc"] = attrOf(s, "data-signature")
+		attr["image"] = attrOf(s, "data-headimg")
+		if biz := attrOf(s, "data-id"); biz != "" {
+			attr["url"] = "https://mp.weixin.qq.com/mp/profile_ext?action=home&__biz=" + url.QueryEscape(biz)
+		}
+		return []Piece{{EMBED_CARD, nil, attr}}
+	}
+	return nil
+}
+
+// attrOf 依次取 names 中第一个非空的属性值
+func attrOf(s *goquery.Selection, names ...string) string {
+	for _, name := range names {
+		if val, _ := s.Attr(name); strings.TrimSpace(val) != "" {
+			return strings.TrimSpace(val)
+		}
+	}
+	return ""
+}
+
+func absoluteURL(src string) string {
+	if strings.HasPrefix(src, "//") {
+		return "https:" + src
+	}
+	if strings.HasPrefix(src, "/") {
+		return "https://mp.weixin.qq.com" + src
+	}
+	return src
+}
+
+func queryValue(rawURL string, key string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get(key)
+}
+
+// unescapeURL data-cover 等属性里的地址是经过 url 编码的
+func unescapeURL(val string) string {
+	if unescaped, err := url.QueryUnescape(val); err == nil {
+		return unescaped
+	}
+	return val
+}
+
+// secondsOf 以秒为单位的时长，可能带小数，四舍五入成整数
+func secondsOf(val string) string {
+	sec, err := strconv.ParseFloat(val, 64)
+	if err != nil || sec <= 0 {
+		return ""
+	}
+	return strconv.Itoa(int(sec + 0.5))
+}
+
+func millisToSeconds(val string) string {
+	ms, err := strconv.Atoi(val)
+	if err != nil || ms <= 0 {
+		return ""
+	}
+	return strconv.Itoa((ms + 500) / 1000)
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, val := range vals {
+		if val != "" {
+			return val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package parse
+
+import "testing"
+
+func TestParseMedia(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		typ     PieceType
+		want    map[string]string
+	}{
+		{
+			name: "mpvideo",
+			content: `<iframe class="video_iframe" data-mpvid="wxv_1" data-cover="https%3A%2F%2Fmmbiz.qpic.cn%2Fc.jpg" data-title="发布会" data-duration="95.6" data-vw="1280" data-vh="720"
+				data-src="https://mp.weixin.qq.com/mp/readtemplate?t=pages/video_player_tmpl&amp;action=mpvideo&amp;vid=wxv_1"></iframe>`,
+			typ: VIDEO,
+			want: map[string]string{
+				"provider": "mpvideo",
+				"src":      "https://mp.weixin.qq.com/mp/readtemplate?t=pages/video_player_tmpl&action=mpvideo&vid=wxv_1",
+				"poster":   "https://mmbiz.qpic.cn/c.jpg",
+				"title":    "发布会",
+				"duration": "96",
+				"width":    "1280",
+				"height":   "720",
+			},
+		},
+		{
+			name:    "mpvideo play length in milliseconds",
+			content: `<iframe data-mpvid="wxv_2" data-play_length="61400" data-src="https://mp.weixin.qq.com/mp/readtemplate?action=mpvideo&amp;vid=wxv_2"></iframe>`,
+			typ:     VIDEO,
+			want:    map[string]string{"provider": "mpvideo", "duration": "61"},
+		},
+		{
+			name:    "tencent video",
+			content: `<iframe data-src="https://v.qq.com/txp/iframe/player.html?vid=abc"></iframe>`,
+			typ:     VIDEO,
+			want: map[string]string{
+				"provider": "tencent",
+				"src":      "https://v.qq.com/x/page/abc.html",
+				"embed":    "https://v.qq.com/txp/iframe/player.html?vid=abc",
+			},
+		},
+		{
+			name:    "channels video",
+			content: `<mp-common-videosnap data-url="https://finder.video.qq.com/p.jpg" data-desc="视频号"></mp-common-videosnap>`,
+			typ:     VIDEO,
+			want:    map[string]string{"provider": "channels", "poster": "https://finder.video.qq.com/p.jpg", "title": "视频号"},
+		},
+		{
+			name:    "mpvoice",
+			content: `<mpvoice voice_encode_fileid="MzA" name="录音" play_length="125000"></mpvoice>`,
+			typ:     AUDIO,
+			want: map[string]string{
+				"provider": "mpvoice",
+				"src":      "https://res.wx.qq.com/voice/getvoice?mediaid=MzA",
+				"title":    "录音",
+				"duration": "125",
+			},
+		},
+		{
+			name:    "qqmusic",
+			content: `<qqmusic audiourl="https://music/a.m4a" music_name="歌" singer="歌手" play_length="200000"></qqmusic>`,
+			typ:     AUDIO,
+			want:    map[string]string{"provider": "qqmusic", "src": "https://music/a.m4a", "title": "歌", "author": "歌手", "duration": "200"},
+		},
+		{
+			name:    "miniprogram",
+			content: `<mp-miniprogram data-miniprogram-appid="wx1" data-miniprogram-path="pages/index" data-miniprogram-title="小程序"></mp-miniprogram>`,
+			typ:     EMBED_CARD,
+			want:    map[string]string{"kind": "miniprogram", "appid": "wx1", "path": "pages/index", "title": "小程序"},
+		},
+		{
+			name:    "profile",
+			content: `<mp-common-profile data-id="MzI=" data-nickname="公众号" data-signature="简介"></mp-common-profile>`,
+			typ:     EMBED_CARD,
+			want: map[string]string{
+				"kind":  "profile",
+				"title": "公众号",
+				"desc":  "简介",
+				"url":   "https://mp.weixin.qq.com/mp/profile_ext?action=home&__biz=MzI%3D",
+			},
+		},
+		{
+			name:    "other iframe",
+			content: `<iframe src="https://example.com/embed"></iframe>`,
+			typ:     EMBED_CARD,
+			want:    map[string]string{"kind": "iframe", "url": "https://example.com/embed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := collect(parseContent(t, tt.content, Options{}), tt.typ)
+			if len(found) != 1 {
+				t.Fatalf("got %d pieces of type %d, want 1", len(found), tt.typ)
+			}
+			checkAttrs(t, tt.name, found[0].Attrs, tt.want)
+		})
+	}
+}
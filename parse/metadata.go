@@ -0,0 +1,134 @@
+package parse
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 页面 script 里的变量有几种写法：
+//
+//	var biz = "" || "MzA5";
+//	var msg_desc = htmlDecode("摘要");
+//	window.ip_wording = { provinceName: '浙江', ... };
+//	content_noencode: JsDecode('...'),
+//	{"cdn_url":"https://..."}
+const jsVarPattern = `(?:\bvar\s+|\.|[{,\s])["']?%s["']?\s*[=:]\s*(?:(?:htmlDecode|JsDecode)\()?\s*` + jsStringPattern + `(?:\s*\|\|\s*` + jsStringPattern + `)?`
+
+// 单引号或双引号的js字符串
+const jsStringPattern = `(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)')`
+
+var jsEscapeReg = regexp.MustCompile(`\\(x[0-9a-fA-F]{2}|u[0-9a-fA-F]{4}|.)`)
+
+// parseMetadata 从整个页面中提取文章的元信息
+func parseMetadata(doc *goquery.Document) Metadata {
+	var md Metadata
+	script := doc.Find("script").Text()
+
+	md.AccountName = firstNonEmpty(
+		removeBrAndBlank(strings.TrimSpace(doc.Find("#js_name").Text())),
+		jsVar(script, "nickname"),
+		jsVar(script, "nick_name"),
+	)
+	md.AccountID = jsVar(script, "user_name")
+	md.Author = firstNonEmpty(
+		metaContent(doc, "meta[name='author']"),
+		strings.TrimSpace(doc.Find("#js_author_name").Text()),
+		jsVar(script, "author"),
+	)
+	md.URL = firstNonEmpty(
+		jsVar(script, "msg_link"),
+		metaContent(doc, "meta[property='og:url']"),
+	)
+	md.Biz = firstNonEmpty(jsVar(script, "biz"), queryValue(md.URL, "__biz"))
+	md.Mid = firstNonEmpty(jsVar(script, "mid"), queryValue(md.URL, "mid"))
+	md.Idx = firstNonEmpty(jsVar(script, "idx"), queryValue(md.URL, "idx"))
+	md.Sn = firstNonEmpty(jsVar(script, "sn"), queryValue(md.URL, "sn"))
+	md.Cover = firstNonEmpty(
+		jsVar(script, "msg_cdn_url"),
+		metaContent(doc, "meta[property='og:image']"),
+	)
+	md.Digest = firstNonEmpty(
+		jsVar(script, "msg_desc"),
+		metaContent(doc, "meta[property='og:description']"),
+		metaContent(doc, "meta[name='description']"),
+	)
+	md.SourceURL = jsVar(script, "msg_source_url")
+	md.ShareURL, md.ShareAccount = parseShareSource(doc)
+	md.IsOriginal = firstNonEmpty(jsVar(script, "copyright_stat"), jsVar(script, "_copyright_stat")) == "1" ||
+		strings.Contains(doc.Find("#copyright_logo").Text(), "原创")
+	md.IPLocation = firstNonEmpty(jsVar(script, "provinceName"), jsVar(script, "countryName"))
+
+	if ct := firstNonEmpty(jsVar(script, "ct"), jsVar(script, "create_time")); ct != "" {
+		if timestamp, err := strconv.ParseInt(ct, 10, 64); err == nil && timestamp > 0 {
+			md.PublishTime = time.Unix(timestamp, 0)
+		}
+	}
+	return md
+}
+
+// 转载、分享的文章顶部的提示，如“以下文章来源于 公众号名”
+var shareNoticeReg = regexp.MustCompile(`(?:以下文章来源于|转载自|分享自)\s*[:：]?\s*([^\s，,。]+)`)
+
+// parseShareSource 转载、分享的文章（#js_share_source、“转载”提示）指向的原文链接和公众号
+func parseShareSource(doc *goquery.Document) (string, string) {
+	source := doc.Find("#js_share_source").First()
+	shareURL := attrOf(source, "href", "data-url", "data-link")
+	if !strings.HasPrefix(shareURL, "http") {
+		shareURL = ""
+	}
+	account := strings.TrimSpace(doc.Find("#js_share_author,#js_share_source .account_nickname_inner,.share_notice .account_nickname_inner").First().Text())
+	if account == "" {
+		// 提示在正文外面，正文里的同样文字不算
+		notice := doc.Find("#img-content,#js_share_notice,.share_notice").First().Clone()
+		notice.Find("#js_content,script,style").Remove()
+		if m := shareNoticeReg.FindStringSubmatch(strings.Join(strings.Fields(notice.Text()), " ")); m != nil {
+			account = m[1]
+		}
+	}
+	return shareURL, account
+}
+
+// jsVar 取 script 中名为 name 的变量的值，多处定义时取第一个非空的值
+func jsVar(script string, name string) string {
+	reg := regexp.MustCompile(strings.Replace(jsVarPattern, "%s", regexp.QuoteMeta(name), 1))
+	for _, m := range reg.FindAllStringSubmatch(script, -1) {
+		if val := firstNonEmpty(m[1], m[2], m[3], m[4]); val != "" {
+			return unescapeJS(val)
+		}
+	}
+	return ""
+}
+
+// unescapeJS 还原js字符串中的 \xNN、\uNNNN、\n 等转义以及html实体
+func unescapeJS(val string) string {
+	val = jsEscapeReg.ReplaceAllStringFunc(val, func(esc string) string {
+		switch esc[1] {
+		case 'x', 'u':
+			if len(esc) > 2 {
+				n, err := strconv.ParseUint(esc[2:], 16, 32)
+				if err != nil {
+					return esc
+				}
+				return string(rune(n))
+			}
+		case 'n':
+			return "\n"
+		case 'r':
+			return "\r"
+		case 't':
+			return "\t"
+		}
+		return esc[1:]
+	})
+	return strings.TrimSpace(html.UnescapeString(val))
+}
+
+func metaContent(doc *goquery.Document, selector string) string {
+	content, _ := doc.Find(selector).First().Attr("content")
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,80 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestJsVar(t *testing.T) {
+	tests := []struct {
+		script string
+		name   string
+		want   string
+	}{
+		{`var biz = "" || "MzA5";`, "biz", "MzA5"},
+		{`var msg_desc = htmlDecode("摘要&amp;说明");`, "msg_desc", "摘要&说明"},
+		{`window.ip_wording = { provinceName: '浙江', countryName: '中国' };`, "provinceName", "浙江"},
+		{`	content_noencode: JsDecode('a\x26b\n中'),`, "content_noencode", "a&b\n中"},
+		{`{"cdn_url":"https:\/\/a.com\/1.jpg"}`, "cdn_url", "https://a.com/1.jpg"},
+		{`var msg_title = ''; var msg_title = "第二处";`, "msg_title", "第二处"},
+		// 名字只是其它变量名的一部分时不算
+		{`var my_biz = "x";`, "biz", ""},
+	}
+	for _, tt := range tests {
+		if got := jsVar(tt.script, tt.name); got != tt.want {
+			t.Errorf("jsVar(%q, %q) = %q, want %q", tt.script, tt.name, got, tt.want)
+		}
+	}
+}
+
+const metadataPage = `<html><head>
+<meta name="author" content="张三">
+<meta property="og:url" content="https://mp.weixin.qq.com/s?__biz=MzA5&amp;mid=2650&amp;idx=1&amp;sn=abc">
+<meta property="og:image" content="https://mmbiz.qpic.cn/cover.jpg">
+<meta property="og:description" content="页面摘要">
+<script>
+var nickname = htmlDecode("脚本里的公众号");
+var user_name = "gh_123456";
+var ct = "1714523400";
+var msg_source_url = 'https://example.com/source';
+var copyright_stat = "1";
+window.ip_wording = { countryName: '中国', provinceName: '浙江' };
+</script></head><body>
+<div id="img-content"><h1 id="activity-name">标题</h1><span id="js_name"> 页面上的公众号 </span>
+<div id="js_content"><p>正文</p></div></div></body></html>`
+
+func TestParseMetadata(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(metadataPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := parseMetadata(doc)
+	want := Metadata{
+		AccountName: "页面上的公众号",
+		AccountID:   "gh_123456",
+		Biz:         "MzA5",
+		Author:      "张三",
+		Mid:         "2650",
+		Idx:         "1",
+		Sn:          "abc",
+		URL:         "https://mp.weixin.qq.com/s?__biz=MzA5&mid=2650&idx=1&sn=abc",
+		PublishTime: time.Unix(1714523400, 0),
+		Cover:       "https://mmbiz.qpic.cn/cover.jpg",
+		Digest:      "页面摘要",
+		IsOriginal:  true,
+		SourceURL:   "https://example.com/source",
+		IPLocation:  "浙江",
+	}
+	if got != want {
+		t.Errorf("parseMetadata:\n got: %+v\nwant: %+v", got, want)
+	}
+
+	// 没有这些变量的页面各字段为空，发布时间为零值
+	doc, _ = goquery.NewDocumentFromReader(strings.NewReader(`<html><body><div id="js_content">正文</div></body></html>`))
+	if md := parseMetadata(doc); md != (Metadata{}) {
+		t.Errorf("empty page: got %+v", md)
+	}
+}
@@ -3,13 +3,37 @@ package parse
 import (
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Article struct {
-	Title   Piece
-	Meta    []string
-	Tags    string
-	Content []Piece
+	Title    Piece
+	Meta     []string
+	Metadata Metadata
+	Tags     string
+	Content  []Piece
+	// FollowErr FollowShare 时原文转换失败的原因，这时返回的是转载的这一篇
+	FollowErr error
+}
+
+// Metadata 文章的元信息，来自页面 script 中的变量和 meta 标签
+type Metadata struct {
+	AccountName  string    // 公众号名称
+	AccountID    string    // 公众号原始ID，如 gh_xxxx
+	Biz          string    // 公众号的 __biz
+	Author       string    // 作者
+	Mid          string    // 文章的 mid
+	Idx          string    // 文章在当次推送中的位置 idx
+	Sn           string    // 文章的 sn
+	URL          string    // 文章的规范链接
+	PublishTime  time.Time // 发布时间，未找到时为零值
+	Cover        string    // 封面图 msg_cdn_url
+	Digest       string    // 摘要 msg_desc
+	IsOriginal   bool      // 是否原创
+	SourceURL    string    // “阅读原文”链接 msg_source_url
+	ShareURL     string    // 转载、分享的文章指向的原文链接
+	ShareAccount string    // 转载、分享的原文所在的公众号
+	IPLocation   string    // 发布时的IP属地
 }
 
 func (article Article) ToString() string {
@@ -43,6 +67,51 @@ type Header struct {
 	Text  string
 }
 
+// Table 表格，TABLE 类型 Piece 的 Val
+type Table struct {
+	Rows      []TableRow
+	HasHeader bool // 第一行是否为表头
+}
+
+// TableRow 表格的一行
+type TableRow []TableCell
+
+// TableCell 单元格
+type TableCell struct {
+	Content  []Piece
+	IsHeader bool
+	ColSpan  int
+	RowSpan  int
+	Align    string // left / center / right，未指定时为空
+}
+
+// HasSpan 是否含有跨行或跨列的单元格
+func (t Table) HasSpan() bool {
+	for _, row := range t.Rows {
+		for _, cell := range row {
+			if cell.ColSpan > 1 || cell.RowSpan > 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ColCount 表格的列数（按最长的一行计，跨列的单元格按跨的列数计）
+func (t Table) ColCount() int {
+	var count int
+	for _, row := range t.Rows {
+		var n int
+		for _, cell := range row {
+			n += cell.ColSpan
+		}
+		if n > count {
+			count = n
+		}
+	}
+	return count
+}
+
 type Value any
 
 type Piece struct {
@@ -54,21 +123,42 @@ type Piece struct {
 type PieceType int32
 
 const (
-	HEADER           PieceType = iota // 0  标题
-	LINK                              // 1  链接
-	NORMAL_TEXT                       // 2  文字
-	BOLD_TEXT                         // 3  粗体文字
-	ITALIC_TEXT                       // 4  斜体文字
-	BOLD_ITALIC_TEXT                  // 5  粗斜体
-	IMAGE                             // 6  图片
-	IMAGE_BASE64                      // 7  图片 base64
-	TABLE                             // 8  表格
-	CODE_INLINE                       // 9  代码 内联
-	CODE_BLOCK                        // 10  代码 块
-	BLOCK_QUOTES                      // 11 引用
-	O_LIST                            // 12 有序列表
-	U_LIST                            // 13 无序列表
-	HR                                // 14 分隔线
-	BR                                // 15 换行
-	NULL                              // 无
+	HEADER             PieceType = iota // 0  标题
+	LINK                                // 1  链接
+	NORMAL_TEXT                         // 2  文字
+	BOLD_TEXT                           // 3  粗体文字
+	ITALIC_TEXT                         // 4  斜体文字
+	BOLD_ITALIC_TEXT                    // 5  粗斜体
+	IMAGE                               // 6  图片
+	IMAGE_BASE64                        // 7  图片 base64
+	TABLE                               // 8  表格
+	CODE_INLINE                         // 9  代码 内联
+	CODE_BLOCK                          // 10  代码 块
+	BLOCK_QUOTES                        // 11 引用
+	O_LIST                              // 12 有序列表
+	U_LIST                              // 13 无序列表
+	HR                                  // 14 分隔线
+	BR                                  // 15 换行
+	STRIKETHROUGH_TEXT                  // 16 删除线文字
+	UNDERLINE_TEXT                      // 17 下划线文字
+	SUP_TEXT                            // 18 上标
+	SUB_TEXT                            // 19 下标
+	VIDEO                               // 20 视频
+	AUDIO                               // 21 音频
+	EMBED_CARD                          // 22 小程序、公众号名片等卡片
+	HIGHLIGHT_TEXT                      // 23 高亮文字
+	MATH                                // 24 公式
+	NULL                                // 无
+)
+
+// 行内文字的修饰标记，多个修饰嵌套叠加时以逗号分隔记录在 Piece.Attrs["marks"] 中
+const (
+	MARK_BOLD      = "bold"
+	MARK_ITALIC    = "italic"
+	MARK_STRIKE    = "strike"
+	MARK_UNDERLINE = "underline"
+	MARK_SUP       = "sup"
+	MARK_SUB       = "sub"
+	MARK_CODE      = "code"
+	MARK_HIGHLIGHT = "highlight"
 )
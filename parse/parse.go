@@ -3,8 +3,10 @@ package parse
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"io/fs"
 	"net/http"
 	"os"
 	"regexp"
@@ -15,57 +17,100 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
-func parseSection(s *goquery.Selection, imagePolicy ImagePolicy, lastPieceType PieceType) []Piece {
+func parseSection(s *goquery.Selection, opts *Options, lastPieceType PieceType) ([]Piece, error) {
 	var pieces []Piece
+	var err error
 	if lastPieceType == O_LIST || lastPieceType == U_LIST || lastPieceType == NULL || lastPieceType == BLOCK_QUOTES {
 		// pieces = append(pieces, Piece{NULL, nil, nil})
 	} else {
 		pieces = append(pieces, Piece{BR, nil, nil})
 	}
 	var _lastPieceType PieceType = NULL
-	s.Contents().Each(func(i int, sc *goquery.Selection) {
+	s.Contents().EachWithBreak(func(i int, sc *goquery.Selection) bool {
+		var sub []Piece
 		attr := make(map[string]string)
+		if !sc.Is("svg") && !isMath(sc) {
+			// 背景图在元素内容的前面
+			pieces = append(pieces, backgroundImages(sc, opts)...)
+		}
 		if sc.Is("a") {
 			attr["href"], _ = sc.Attr("href")
 			pieces = append(pieces, Piece{LINK, removeBrAndBlank(sc.Text()), attr})
+		} else if isMath(sc) {
+			if sub, err = parseMath(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		} else if sc.Is("svg") {
+			if sub, err = parseSVG(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
 		} else if sc.Is("img") {
-			attr["src"], _ = sc.Attr("data-src")
-			attr["alt"], _ = sc.Attr("alt")
-			attr["title"], _ = sc.Attr("title")
-			switch imagePolicy {
-			case IMAGE_POLICY_URL:
-				pieces = append(pieces, Piece{IMAGE, nil, attr})
-			case IMAGE_POLICY_SAVE:
-				image := fetchImgFile(attr["src"])
-				pieces = append(pieces, Piece{IMAGE, image, attr})
-			case IMAGE_POLICY_BASE64:
-				fallthrough
-			default:
-				base64Image := img2base64(fetchImgFile(attr["src"]))
-				pieces = append(pieces, Piece{IMAGE_BASE64, base64Image, attr})
+			var image Piece
+			if image, err = parseImage(sc, opts); err != nil {
+				return false
 			}
+			pieces = append(pieces, image)
+		} else if isMedia(sc) {
+			// 视频、音频、小程序、名片
+			pieces = append(pieces, parseMedia(sc)...)
 		} else if sc.Is("ol") {
-			pieces = append(pieces, parseList(sc, O_LIST, imagePolicy)...)
+			if sub, err = parseList(sc, O_LIST, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
 		} else if sc.Is("ul") {
-			pieces = append(pieces, parseList(sc, U_LIST, imagePolicy)...)
+			if sub, err = parseList(sc, U_LIST, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
 		} else if sc.Is("pre") || sc.Is("section.code-snippet__fix") {
 			// 代码块
-			pieces = append(pieces, parsePre(sc)...)
+			if sub, err = parsePre(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		} else if marks := styleMarks(sc); sc.Is("span") && len(marks) > 0 {
+			// 靠内联样式加粗、倾斜的文字
+			if sub, err = parseInline(sc, opts, marks); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
 		} else if sc.Is("span") || sc.Is("figure") {
-			pieces = append(pieces, parseSection(sc, imagePolicy, _lastPieceType)...)
+			if sub, err = parseSection(sc, opts, _lastPieceType); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		} else if level := inferHeader(sc, opts); level > 0 {
+			// 靠内联样式（字号、加粗、居中、颜色）排版的小标题
+			attr := map[string]string{"level": strconv.Itoa(level), "inferred": "true"}
+			pieces = append(pieces, Piece{HEADER, strings.TrimSpace(removeBrAndBlank(sc.Text())), attr})
 		} else if sc.Is("p") || sc.Is("section") || sc.Is("figcaption") {
-			pieces = append(pieces, parseSection(sc, imagePolicy, _lastPieceType)...)
+			if sub, err = parseSection(sc, opts, _lastPieceType); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
 			if removeBrAndBlank(sc.Text()) != "" && len(pieces) > 0 && pieces[len(pieces)-1].Type != BR {
 				pieces = append(pieces, Piece{BR, nil, nil})
 			}
 		} else if sc.Is("h1") || sc.Is("h2") || sc.Is("h3") || sc.Is("h4") || sc.Is("h5") || sc.Is("h6") {
 			pieces = append(pieces, parseHeader(sc)...)
 		} else if sc.Is("blockquote") {
-			pieces = append(pieces, parseBlockQuote(sc, imagePolicy)...)
-		} else if sc.Is("strong") {
-			pieces = append(pieces, parseStrong(sc)...)
+			if sub, err = parseBlockQuote(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		} else if mark := inlineMark(sc); mark != "" {
+			if sub, err = parseInline(sc, opts, []string{mark}); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
 		} else if sc.Is("table") {
-			pieces = append(pieces, parseTable(sc)...)
+			if sub, err = parseTable(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
 		} else {
 			if sc.Text() != "" {
 				pieces = append(pieces, Piece{NORMAL_TEXT, sc.Text(), nil})
@@ -74,8 +119,18 @@ func parseSection(s *goquery.Selection, imagePolicy ImagePolicy, lastPieceType P
 		if len(pieces) > 0 {
 			_lastPieceType = pieces[len(pieces)-1].Type
 		}
+		return true
 	})
-	return pieces
+	return mergeInlinePieces(pieces), err
+}
+
+func parseImage(s *goquery.Selection, opts *Options) (Piece, error) {
+	attr := make(map[string]string)
+	attr["src"], _ = s.Attr("data-src")
+	attr["alt"], _ = s.Attr("alt")
+	attr["title"], _ = s.Attr("title")
+	// SAVE、BASE64 的图片内容在正文解析完后由 fetchImages 统一下载
+	return Piece{IMAGE, nil, attr}, nil
 }
 
 func parseHeader(s *goquery.Selection) []Piece {
@@ -99,54 +154,195 @@ func parseHeader(s *goquery.Selection) []Piece {
 	return []Piece{p}
 }
 
-func parsePre(s *goquery.Selection) []Piece {
-	// TODO when include img...
-	var codeRows []string
-	s.Find("code").Each(func(i int, sc *goquery.Selection) {
-		var codeLine string = ""
-		sc.Contents().Each(func(i int, sc *goquery.Selection) {
-			if goquery.NodeName(sc) == "br" {
-				codeRows = append(codeRows, codeLine)
-				codeLine = ""
+// parseList 解析 ol/ul，只取直接的 li，嵌套的子列表作为所在列表项内容的一部分，保持层级
+func parseList(s *goquery.Selection, ptype PieceType, opts *Options) ([]Piece, error) {
+	var list []Piece
+	var err error
+	number := 1
+	if start, exists := s.Attr("start"); exists {
+		if n, e := strconv.Atoi(strings.TrimSpace(start)); e == nil {
+			number = n
+		}
+	}
+	s.Children().EachWithBreak(func(i int, sc *goquery.Selection) bool {
+		var sub []Piece
+		if sc.Is("li") {
+			if value, exists := sc.Attr("value"); exists {
+				if n, e := strconv.Atoi(strings.TrimSpace(value)); e == nil {
+					number = n
+				}
+			}
+			if sub, err = parseSection(sc, opts, ptype); err != nil {
+				return false
+			}
+			var attr map[string]string
+			if ptype == O_LIST {
+				attr = map[string]string{"number": strconv.Itoa(number)}
+			}
+			list = append(list, Piece{ptype, trimBlank(sub), attr})
+			number++
+		} else if sc.Is("ol") || sc.Is("ul") {
+			// 子列表直接写在 ol/ul 里（不在 li 中），归到上一个列表项下
+			subType := U_LIST
+			if sc.Is("ol") {
+				subType = O_LIST
+			}
+			if sub, err = parseList(sc, subType, opts); err != nil {
+				return false
+			}
+			if len(list) > 0 {
+				last := &list[len(list)-1]
+				last.Val = append(last.Val.([]Piece), sub...)
 			} else {
-				codeLine += sc.Text()
+				list = append(list, sub...)
 			}
-		})
-		codeRows = append(codeRows, codeLine)
+		}
+		return true
 	})
-	p := Piece{CODE_BLOCK, codeRows, nil}
-	return []Piece{p}
+	return list, err
 }
 
-func parseList(s *goquery.Selection, ptype PieceType, imagePolicy ImagePolicy) []Piece {
-	var list []Piece
-	s.Find("li").Each(func(i int, sc *goquery.Selection) {
-		list = append(list, Piece{ptype, parseSection(sc, imagePolicy, ptype), nil})
-	})
-	return list
+// parseBlockQuote 整个引用作为一个 BLOCK_QUOTES，其中的列表、图片等照常解析
+func parseBlockQuote(s *goquery.Selection, opts *Options) ([]Piece, error) {
+	quote, err := parseSection(s, opts, BLOCK_QUOTES)
+	if err != nil {
+		return nil, err
+	}
+	return []Piece{{BLOCK_QUOTES, trimBlank(quote), nil}, {BR, nil, nil}}, nil
 }
 
-func parseBlockQuote(s *goquery.Selection, imagePolicy ImagePolicy) []Piece {
-	var bq []Piece
-	s.Contents().Each(func(i int, sc *goquery.Selection) {
-		bq = append(bq, Piece{BLOCK_QUOTES, parseSection(sc, imagePolicy, BLOCK_QUOTES), nil})
+// 行内修饰标签 => 修饰标记
+var inlineMarkTags = map[string]string{
+	"strong": MARK_BOLD,
+	"b":      MARK_BOLD,
+	"em":     MARK_ITALIC,
+	"i":      MARK_ITALIC,
+	"del":    MARK_STRIKE,
+	"s":      MARK_STRIKE,
+	"strike": MARK_STRIKE,
+	"u":      MARK_UNDERLINE,
+	"ins":    MARK_UNDERLINE,
+	"sup":    MARK_SUP,
+	"sub":    MARK_SUB,
+	"code":   MARK_CODE,
+	"kbd":    MARK_CODE,
+	"tt":     MARK_CODE,
+	"mark":   MARK_HIGHLIGHT,
+}
+
+// 修饰标记的固定顺序，保证相同的修饰组合得到相同的 marks 值
+var markOrder = []string{MARK_BOLD, MARK_ITALIC, MARK_STRIKE, MARK_UNDERLINE, MARK_HIGHLIGHT, MARK_SUP, MARK_SUB, MARK_CODE}
+
+func inlineMark(s *goquery.Selection) string {
+	return inlineMarkTags[goquery.NodeName(s)]
+}
+
+// parseInline 解析行内修饰标签（strong/em/del/u/sup/sub/code 等），marks 为外层已叠加的修饰
+func parseInline(s *goquery.Selection, opts *Options, marks []string) ([]Piece, error) {
+	var pieces []Piece
+	var err error
+	s.Contents().EachWithBreak(func(i int, sc *goquery.Selection) bool {
+		var sub []Piece
+		if !sc.Is("svg") && !isMath(sc) {
+			pieces = append(pieces, backgroundImages(sc, opts)...)
+		}
+		if goquery.NodeName(sc) == "#text" {
+			if text := sc.Text(); text != "" {
+				pieces = append(pieces, inlinePiece(text, marks))
+			}
+		} else if mark := inlineMark(sc); mark != "" {
+			if sub, err = parseInline(sc, opts, append(marks[:len(marks):len(marks)], mark)); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		} else if isMath(sc) {
+			if sub, err = parseMath(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		} else if sc.Is("svg") {
+			if sub, err = parseSVG(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		} else if sc.Is("a") {
+			attr := map[string]string{}
+			attr["href"], _ = sc.Attr("href")
+			pieces = append(pieces, Piece{LINK, removeBrAndBlank(sc.Text()), attr})
+		} else if sc.Is("img") {
+			var image Piece
+			if image, err = parseImage(sc, opts); err != nil {
+				return false
+			}
+			pieces = append(pieces, image)
+		} else if isMedia(sc) {
+			pieces = append(pieces, parseMedia(sc)...)
+		} else if sc.Is("br") {
+			pieces = append(pieces, Piece{BR, nil, nil})
+		} else {
+			if sub, err = parseInline(sc, opts, append(marks[:len(marks):len(marks)], styleMarks(sc)...)); err != nil {
+				return false
+			}
+			pieces = append(pieces, sub...)
+		}
+		return true
 	})
-	bq = append(bq, Piece{BR, nil, nil})
-	return bq
+	return mergeInlinePieces(pieces), err
 }
 
-func parseTable(s *goquery.Selection) []Piece {
-	// 先简单粗暴把原生的挪过去
-	var table []Piece
-	html, _ := s.Html()
-	table = append(table, Piece{TABLE, "<table>" + html + "</table>", map[string]string{"type": "native"}})
-	return table
+// inlinePiece 根据叠加的修饰标记生成对应类型的文字 Piece
+func inlinePiece(text string, marks []string) Piece {
+	has := make(map[string]bool)
+	for _, m := range marks {
+		has[m] = true
+	}
+	if strings.TrimSpace(text) == "" || len(has) == 0 {
+		return Piece{NORMAL_TEXT, text, nil}
+	}
+	var ordered []string
+	for _, m := range markOrder {
+		if has[m] {
+			ordered = append(ordered, m)
+		}
+	}
+	var ptype PieceType
+	switch {
+	case has[MARK_CODE]:
+		ptype = CODE_INLINE
+	case has[MARK_SUP]:
+		ptype = SUP_TEXT
+	case has[MARK_SUB]:
+		ptype = SUB_TEXT
+	case has[MARK_STRIKE]:
+		ptype = STRIKETHROUGH_TEXT
+	case has[MARK_UNDERLINE]:
+		ptype = UNDERLINE_TEXT
+	case has[MARK_HIGHLIGHT]:
+		ptype = HIGHLIGHT_TEXT
+	case has[MARK_BOLD] && has[MARK_ITALIC]:
+		ptype = BOLD_ITALIC_TEXT
+	case has[MARK_BOLD]:
+		ptype = BOLD_TEXT
+	default:
+		ptype = ITALIC_TEXT
+	}
+	return Piece{ptype, text, map[string]string{"marks": strings.Join(ordered, ",")}}
 }
 
-func parseStrong(s *goquery.Selection) []Piece {
-	var bt []Piece
-	bt = append(bt, Piece{BOLD_TEXT, strings.TrimSpace(s.Text()), nil})
-	return bt
+// mergeInlinePieces 合并相邻且修饰相同的行内文字，避免输出 **a****b** 这样的结果
+func mergeInlinePieces(pieces []Piece) []Piece {
+	var merged []Piece
+	for _, p := range pieces {
+		if n := len(merged); n > 0 && p.Attrs["marks"] != "" {
+			last := merged[n-1]
+			if last.Type == p.Type && last.Attrs["marks"] == p.Attrs["marks"] {
+				merged[n-1].Val = last.Val.(string) + p.Val.(string)
+				continue
+			}
+		}
+		merged = append(merged, p)
+	}
+	return merged
 }
 
 func parseMeta(s *goquery.Selection) []string {
@@ -166,31 +362,45 @@ func parseMeta(s *goquery.Selection) []string {
 	return res
 }
 
-func ParseFromReader(r io.Reader, imagePolicy ImagePolicy) Article {
+// ParseFromReader 从 r 中读取公众号文章的 html 并解析，找不到正文时返回 ErrNoContent
+func ParseFromReader(r io.Reader, imagePolicy ImagePolicy) (Article, error) {
+	return ParseFromReaderWithOptions(r, Options{ImagePolicy: imagePolicy})
+}
+
+// ParseFromReaderWithOptions 同 ParseFromReader，可指定更多解析选项
+func ParseFromReaderWithOptions(r io.Reader, opts Options) (Article, error) {
 	var article Article
 	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
-		log.Fatal(err)
+		return article, fmt.Errorf("parse html error: %w", err)
 	}
 	var mainContent *goquery.Selection = doc.Find("#img-content")
+	// 文字、图片、视频消息的内容在 script 变量里，页面上可能没有 #img-content
+	post, isScriptPost := findScriptPost(doc.Find("script").Text(), mainContent.Find("#js_content"))
+	if mainContent.Length() == 0 && !isScriptPost {
+		if err := unavailablePage(doc); err != nil {
+			return article, err
+		}
+		return article, ErrNoContent
+	}
+
+	// 从js变量和meta标签中提取元信息
+	article.Metadata = parseMetadata(doc)
 
-	// 标题
-	title := mainContent.Find("#activity-name").Text()
+	// 标题，文字、图片消息可能没有 #activity-name
+	title := removeBrAndBlank(mainContent.Find("#activity-name").Text())
+	if title == "" {
+		title = removeBrAndBlank(jsVar(doc.Find("script").Text(), "msg_title"))
+	}
 	attr := map[string]string{"level": "1"}
-	article.Title = Piece{HEADER, removeBrAndBlank(title), attr}
+	article.Title = Piece{HEADER, title, attr}
 
 	// meta
 	meta := mainContent.Find("#meta_content")
 	metastring := parseMeta(meta)
 	article.Meta = metastring
-	// 从js中找到发布时间
-	re, _ := regexp.Compile("var ct = \"([0-9]+)\"")
-	findstrs := re.FindStringSubmatch(doc.Find("script").Text())
-	if len(findstrs) > 1 {
-		var createTime string = findstrs[1]
-		timestamp, _ := strconv.Atoi(createTime)
-		time := time.Unix(int64(timestamp), 0)
-		article.Meta = append(article.Meta, time.Format("2006-01-02 15:04"))
+	if !article.Metadata.PublishTime.IsZero() {
+		article.Meta = append(article.Meta, article.Metadata.PublishTime.Format("2006-01-02 15:04"))
 	}
 
 	// tags 细节待完善
@@ -198,50 +408,97 @@ func ParseFromReader(r io.Reader, imagePolicy ImagePolicy) Article {
 	tags = removeBrAndBlank(tags)
 	article.Tags = tags
 
-	// content
-	// section[style="line-height: 1.5em;"]>span,a	=> 一般段落（含文本和超链接）
-	// p[style="line-height: 1.5em;"]				=> 项目列表（有序/无序）
-	// section[style=".*text-align:center"]>img		=> 居中段落（图片）
-	content := mainContent.Find("#js_content")
-	pieces := parseSection(content, imagePolicy, NULL)
+	// 转载、分享的文章改为转换原文。原文转换失败（打不开、网络错误等）时仍转换这一篇，原因记在 FollowErr 中
+	if opts.FollowShare && article.Metadata.ShareURL != "" {
+		followOpts := opts
+		followOpts.FollowShare = false
+		original, err := ParseFromURLWithOptions(article.Metadata.ShareURL, followOpts)
+		if err == nil {
+			// 保留转载的出处：链接仍是转载的这一篇，转载自原文
+			original.Metadata.URL = article.Metadata.URL
+			original.Metadata.ShareURL = article.Metadata.ShareURL
+			original.Metadata.ShareAccount = article.Metadata.ShareAccount
+			return original, nil
+		}
+		article.FollowErr = fmt.Errorf("follow share %s: %w", article.Metadata.ShareURL, err)
+	}
+
+	if opts.HeaderThreshold == 0 {
+		opts.HeaderThreshold = DefaultHeaderThreshold
+	}
+	var pieces []Piece
+	if isScriptPost {
+		pieces, err = parseScriptPost(post, article.Metadata, title, &opts)
+	} else {
+		// content
+		// section[style="line-height: 1.5em;"]>span,a	=> 一般段落（含文本和超链接）
+		// p[style="line-height: 1.5em;"]				=> 项目列表（有序/无序）
+		// section[style=".*text-align:center"]>img		=> 居中段落（图片）
+		content := mainContent.Find("#js_content")
+		if content.Length() == 0 {
+			return article, ErrNoContent
+		}
+		opts.baseFontSize = baseFontSize(content)
+		pieces, err = parseSection(content, &opts, NULL)
+	}
+	if err != nil {
+		return article, err
+	}
+	if opts.ImagePolicy != IMAGE_POLICY_URL {
+		if pieces, err = fetchImages(pieces, &opts); err != nil {
+			return article, err
+		}
+	}
 	article.Content = pieces
 
-	return article
+	return article, nil
 }
 
-func ParseFromHTMLString(s string, imagePolicy ImagePolicy) Article {
-	return ParseFromReader(strings.NewReader(s), imagePolicy)
+func ParseFromHTMLString(s string, imagePolicy ImagePolicy) (Article, error) {
+	return ParseFromHTMLStringWithOptions(s, Options{ImagePolicy: imagePolicy})
 }
 
-func ParseFromHTMLFile(filepath string, imagePolicy ImagePolicy) Article {
+func ParseFromHTMLStringWithOptions(s string, opts Options) (Article, error) {
+	return ParseFromReaderWithOptions(strings.NewReader(s), opts)
+}
+
+func ParseFromHTMLFile(filepath string, imagePolicy ImagePolicy) (Article, error) {
+	return ParseFromHTMLFileWithOptions(filepath, Options{ImagePolicy: imagePolicy})
+}
+
+func ParseFromHTMLFileWithOptions(filepath string, opts Options) (Article, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		panic(err)
+		if errors.Is(err, fs.ErrNotExist) {
+			return Article{}, fmt.Errorf("%w: %s", ErrNotFound, filepath)
+		}
+		return Article{}, err
 	}
 	defer file.Close()
-	content, err2 := io.ReadAll(file)
-	if err2 != nil {
-		panic(err2)
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return Article{}, fmt.Errorf("read file %s error: %w", filepath, err)
 	}
-	return ParseFromReader(bytes.NewReader(content), imagePolicy)
+	return ParseFromReaderWithOptions(bytes.NewReader(content), opts)
 }
 
-func ParseFromURL(url string, imagePolicy ImagePolicy) Article {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Fatalf("new request %s error: %s", url, err.Error())
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36 Edg/133.0.0.0")
-	client := &http.Client{}
-	res, err := client.Do(req)
+func ParseFromURL(url string, imagePolicy ImagePolicy) (Article, error) {
+	return ParseFromURLWithOptions(url, Options{ImagePolicy: imagePolicy})
+}
+
+func ParseFromURLWithOptions(url string, opts Options) (Article, error) {
+	res, err := getPage(opts.fetcher(), url)
 	if err != nil {
-		log.Fatalf("request to url %s error: %s", url, err.Error())
+		return Article{}, fmt.Errorf("request to url %s error: %w", url, err)
 	}
 	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return Article{}, fmt.Errorf("%w: %s", ErrNotFound, url)
+	}
 	if res.StatusCode != 200 {
-		log.Fatalf("get from url %s error: %d %s", url, res.StatusCode, res.Status)
+		return Article{}, fmt.Errorf("get from url %s error: %d %s", url, res.StatusCode, res.Status)
 	}
-	return ParseFromReader(res.Body, imagePolicy)
+	return ParseFromReaderWithOptions(res.Body, opts)
 }
 
 func removeBrAndBlank(s string) string {
@@ -257,27 +514,62 @@ func removeBrAndBlank(s string) string {
 	return strings.Replace(string(sb), "\n", " ", -1)
 }
 
-func fetchImgFile(url string) []byte {
-	res, err := http.Get(url)
-	if err != nil {
-		log.Fatalf("get Image from url %s error: %s", url, err.Error())
-		return nil
+// trimBr 去掉首尾的换行
+func trimBr(pieces []Piece) []Piece {
+	for len(pieces) > 0 && pieces[0].Type == BR {
+		pieces = pieces[1:]
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		log.Fatalf("get Image from url %s error: %d %s", url, res.StatusCode, res.Status)
+	for len(pieces) > 0 && pieces[len(pieces)-1].Type == BR {
+		pieces = pieces[:len(pieces)-1]
 	}
-	content, err := io.ReadAll(res.Body)
-	if err != nil {
-		log.Fatalf("read image Response error: %s", err.Error())
+	return pieces
+}
+
+// trimBlank 去掉首尾的换行和空白文字
+func trimBlank(pieces []Piece) []Piece {
+	isBlank := func(p Piece) bool {
+		if p.Type == BR {
+			return true
+		}
+		text, ok := p.Val.(string)
+		return p.Type == NORMAL_TEXT && ok && strings.TrimSpace(text) == ""
 	}
-	return content
+	for len(pieces) > 0 && isBlank(pieces[0]) {
+		pieces = pieces[1:]
+	}
+	for len(pieces) > 0 && isBlank(pieces[len(pieces)-1]) {
+		pieces = pieces[:len(pieces)-1]
+	}
+	return pieces
 }
 
 func img2base64(content []byte) string {
 	return base64.StdEncoding.EncodeToString(content)
 }
 
+// Options 解析选项
+type Options struct {
+	// ImagePolicy 图片处理方式
+	ImagePolicy ImagePolicy
+	// HeaderThreshold 由内联样式推断标题的阈值：段落字号与正文字号之比不小于该值时视为标题，
+	// 值越小越激进；为0时使用 DefaultHeaderThreshold，小于0时不推断标题
+	HeaderThreshold float64
+	// ImageWorkers 同时下载的图片数，为0时使用 DefaultImageWorkers
+	ImageWorkers int
+	// ImageRetries 图片下载失败后的重试次数，为0时使用 DefaultImageRetries，小于0时不重试
+	ImageRetries int
+	// ImageTimeout 单张图片每次下载的超时，为0时使用 DefaultImageTimeout
+	ImageTimeout time.Duration
+	// ImageCache 图片的磁盘缓存，为 nil 时不使用缓存
+	ImageCache *ImageCache
+	// Fetcher 请求页面和下载图片用的 Fetcher，为 nil 时使用默认设置的 Client
+	Fetcher Fetcher
+	// FollowShare 转载、分享的文章改为转换它指向的原文
+	FollowShare bool
+
+	baseFontSize float64 // 正文字号，解析时统计得出
+}
+
 type ImagePolicy int32
 
 const (
@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	const page = "https://mp.weixin.qq.com/s/a"
+	imagePage := fmt.Sprintf(articlePage, `<p><img data-src="https://mmbiz.qpic.cn/gone/0"/></p>`)
+	tests := []struct {
+		name  string
+		parse func() (Article, error)
+		want  error
+		text  string
+	}{
+		{
+			"missing file",
+			func() (Article, error) {
+				return ParseFromHTMLFileWithOptions(filepath.Join(t.TempDir(), "none.html"), Options{})
+			},
+			ErrNotFound, "none.html",
+		},
+		{
+			"page 404",
+			func() (Article, error) {
+				return ParseFromURLWithOptions(page, Options{Fetcher: &fakeFetcher{statuses: map[string][]int{page: {404}}}})
+			},
+			ErrNotFound, page,
+		},
+		{
+			"page 500",
+			func() (Article, error) {
+				return ParseFromURLWithOptions(page, Options{Fetcher: &fakeFetcher{statuses: map[string][]int{page: {500}}}})
+			},
+			nil, "500",
+		},
+		{
+			"not an article",
+			func() (Article, error) { return ParseFromURLWithOptions(page, Options{Fetcher: &fakeFetcher{}}) },
+			ErrNoContent, "",
+		},
+		{
+			"image fetch",
+			func() (Article, error) {
+				fetcher := &fakeFetcher{statuses: map[string][]int{"https://mmbiz.qpic.cn/gone/0": {404}}}
+				return ParseFromHTMLStringWithOptions(imagePage, Options{ImagePolicy: IMAGE_POLICY_SAVE, Fetcher: fetcher})
+			},
+			ErrImageFetch, "gone",
+		},
+	}
+	for _, tt := range tests {
+		_, err := tt.parse()
+		if err == nil {
+			t.Errorf("%s: err = nil", tt.name)
+			continue
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if tt.want == nil && errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: err = %v, want a status error", tt.name, err)
+		}
+		if !strings.Contains(err.Error(), tt.text) {
+			t.Errorf("%s: err = %v, want it to mention %q", tt.name, err, tt.text)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package parse
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 文章类型，页面 script 中的 item_show_type
+const (
+	showTypeVideo   = "5"  // 视频消息
+	showTypePicture = "8"  // 图片消息（贴图）
+	showTypeText    = "10" // 文字消息（短内容）
+)
+
+// scriptPost 内容放在 script 变量里而不是 #js_content 中的文章：文字、图片、视频消息
+type scriptPost struct {
+	kind     string   // showTypeVideo、showTypePicture、showTypeText
+	text     string   // 文字内容 content_noencode，可能是html
+	pictures []string // 图片消息的图片地址
+	video    map[string]string
+}
+
+// findScriptPost 按 item_show_type 和 script 变量判断是否是文字、图片、视频消息。
+// 正文为空的普通文章如果 script 里有 content_noencode，也按文字处理
+func findScriptPost(script string, content *goquery.Selection) (scriptPost, bool) {
+	post := scriptPost{text: jsVar(script, "content_noencode")}
+	for _, obj := range jsObjects(script, "picture_page_info_list") {
+		if src := jsVar(obj, "cdn_url"); src != "" {
+			post.pictures = append(post.pictures, src)
+		}
+	}
+	post.video = scriptVideo(script)
+
+	emptyContent := content.Length() == 0 ||
+		(strings.TrimSpace(content.Text()) == "" && content.Find("img,iframe,video,mpvoice,mp-common-videosnap").Length() == 0)
+	switch showType := jsVar(script, "item_show_type"); {
+	case showType == showTypePicture || emptyContent && len(post.pictures) > 0:
+		post.kind = showTypePicture
+	case showType == showTypeVideo || emptyContent && post.video != nil:
+		post.kind = showTypeVideo
+	case showType == showTypeText || emptyContent && post.text != "":
+		post.kind = showTypeText
+	default:
+		return post, false
+	}
+	return post, true
+}
+
+// scriptVideo 视频消息的视频：__mpVideoTransInfo 中的第一个清晰度（通常是最高的）和封面
+func scriptVideo(script string) map[string]string {
+	var file string
+	for _, name := range []string{"__mpVideoTransInfo", "mp_video_trans_info"} {
+		for _, obj := range jsObjects(script, name) {
+			if file = jsVar(obj, "url"); file != "" {
+				break
+			}
+		}
+		if file != "" {
+			break
+		}
+	}
+	if file == "" {
+		return nil
+	}
+	return map[string]string{
+		"provider": "mpvideo",
+		"file":     file,
+		"poster": firstNonEmpty(
+			jsVar(script, "__mpVideoCoverUrl"),
+			jsVar(script, "mpVideoCoverUrl"),
+			jsVar(script, "cdn_url_1_1"),
+			jsVar(script, "msg_cdn_url"),
+		),
+	}
+}
+
+// parseScriptPost 把文字、图片、视频消息转成与普通文章相同的 Piece：
+// 视频或图片在前（与微信中的显示一致），文字在后
+func parseScriptPost(post scriptPost, md Metadata, title string, opts *Options) ([]Piece, error) {
+	var pieces []Piece
+	switch post.kind {
+	case showTypeVideo:
+		attr := post.video
+		attr["src"] = md.URL
+		attr["title"] = title
+		pieces = append(pieces, Piece{VIDEO, nil, attr}, Piece{BR, nil, nil})
+	case showTypePicture:
+		for _, src := range post.pictures {
+			attr := map[string]string{"src": src, "alt": "", "title": ""}
+			pieces = append(pieces, Piece{IMAGE, nil, attr}, Piece{BR, nil, nil})
+		}
+	}
+	text := post.text
+	if text == "" && post.kind != showTypeText {
+		text = md.Digest
+	}
+	if text == "" {
+		return pieces, nil
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div id="js_content">` + textToHTML(text) + `</div>`))
+	if err != nil {
+		return nil, err
+	}
+	content := doc.Find("#js_content")
+	opts.baseFontSize = baseFontSize(content)
+	sub, err := parseSection(content, opts, NULL)
+	if err != nil {
+		return nil, err
+	}
+	return append(pieces, sub...), nil
+}
+
+var htmlTagReg = regexp.MustCompile(`(?i)<(p|br|a|span|section|div|img|strong|b|em|i|h[1-6])[\s/>]`)
+
+// textToHTML 文字内容是纯文本时按行转成段落，已经是html时原样返回
+func textToHTML(text string) string {
+	if htmlTagReg.MatchString(text) {
+		return text
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			b.WriteString("<p>" + html.EscapeString(line) + "</p>")
+		}
+	}
+	return b.String()
+}
+
+// jsObjects 取 script 中名为 name 的数组里的各个对象，只保留对象第一层的属性（嵌套的对象和数组去掉），
+// 供 jsVar 取值。找不到时返回 nil
+func jsObjects(script string, name string) []string {
+	reg := regexp.MustCompile(`[.{,\s]["']?` + regexp.QuoteMeta(name) + `["']?\s*[=:]\s*\[`)
+	loc := reg.FindStringIndex(script)
+	if loc == nil {
+		return nil
+	}
+	var objects []string
+	var cur strings.Builder
+	depth := 1 // 已经在数组里
+	var quote byte
+	for i := loc[1]; i < len(script) && depth > 0; i++ {
+		c := script[i]
+		if quote != 0 {
+			if depth == 2 {
+				cur.WriteByte(c)
+			}
+			if c == '\\' && i+1 < len(script) {
+				i++
+				if depth == 2 {
+					cur.WriteByte(script[i])
+				}
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+			if depth == 2 {
+				cur.WriteByte(c)
+			}
+		case '{', '[':
+			depth++
+			if depth == 2 {
+				cur.Reset()
+				cur.WriteByte(c)
+			}
+		case '}', ']':
+			if depth == 2 {
+				cur.WriteByte(c)
+				objects = append(objects, cur.String())
+			}
+			depth--
+		default:
+			if depth == 2 {
+				cur.WriteByte(c)
+			}
+		}
+	}
+	return objects
+}
@@ -0,0 +1,137 @@
+package parse
+
+import (
+	"testing"
+)
+
+// scriptPage 内容在 script 变量里的页面，#js_content 为空
+func scriptPage(script string) string {
+	return `<html><head></head><body><div id="img-content"><div id="js_content"></div></div><script>` + script + `</script></body></html>`
+}
+
+func TestScriptPost(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		title string
+		want  string
+	}{
+		{
+			"text post",
+			scriptPage(`var item_show_type = "10";
+var msg_title = '今天的想法';
+var content_noencode = "第一行\n\n第二行 &amp; 更多\x0a第三行";`),
+			"今天的想法",
+			"text(第一行) text(第二行 & 更多) text(第三行)",
+		},
+		{
+			"text post with html",
+			scriptPage(`var item_show_type = "10";
+var content_noencode = '<p>你好<strong>世界</strong></p><p><a href="https://a.com/">链接</a></p>';`),
+			"",
+			"text(你好) bold(世界) link(链接->https://a.com/)",
+		},
+		{
+			"picture post",
+			scriptPage(`var item_show_type = "8";
+var msg_title = "周末";
+var content_noencode = "拍了几张照片";
+window.picture_page_info_list = [
+	{ cdn_url: 'https://mmbiz.qpic.cn/a/0', width: 1080, watermark_info: { cdn_url: 'https://mmbiz.qpic.cn/mark/0' } },
+	{ cdn_url: "https://mmbiz.qpic.cn/b/0", share_cover: [{ cdn_url: "https://mmbiz.qpic.cn/cover/0" }] },
+];`),
+			"周末",
+			"image(https://mmbiz.qpic.cn/a/0) image(https://mmbiz.qpic.cn/b/0) text(拍了几张照片)",
+		},
+		{
+			"picture post without show type",
+			scriptPage(`var picture_page_info_list = [{"cdn_url": "https://mmbiz.qpic.cn/a/0"}];`),
+			"",
+			"image(https://mmbiz.qpic.cn/a/0)",
+		},
+		{
+			"video post uses digest",
+			scriptPage(`var item_show_type = "5";
+var msg_title = "演示视频";
+var msg_desc = "视频的简介";
+var msg_link = "https://mp.weixin.qq.com/s/video";
+var __mpVideoCoverUrl = "https://mmbiz.qpic.cn/cover/0";
+window.__mpVideoTransInfo = [
+	{ format_id: '10002', url: 'https://mpvideo.qpic.cn/hd.mp4', video_quality_level: 3 },
+	{ format_id: '10004', url: 'https://mpvideo.qpic.cn/sd.mp4' },
+];`),
+			"演示视频",
+			"video text(视频的简介)",
+		},
+		{
+			"empty content with text",
+			scriptPage(`var content_noencode = "只有文字";`),
+			"",
+			"text(只有文字)",
+		},
+	}
+	for _, tt := range tests {
+		article, err := ParseFromHTMLStringWithOptions(tt.html, Options{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if title, _ := article.Title.Val.(string); title != tt.title {
+			t.Errorf("%s: title = %q, want %q", tt.name, title, tt.title)
+		}
+		if got := describe(article.Content); got != tt.want {
+			t.Errorf("%s:\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScriptPostVideo(t *testing.T) {
+	article, err := ParseFromHTMLStringWithOptions(scriptPage(`var item_show_type = "5";
+var msg_title = "演示视频";
+var msg_link = "https://mp.weixin.qq.com/s/video";
+var msg_cdn_url = "https://mmbiz.qpic.cn/cover/0";
+var mp_video_trans_info = [{ url: "https://mpvideo.qpic.cn/hd.mp4" }];`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	videos := collect(article.Content, VIDEO)
+	if len(videos) != 1 {
+		t.Fatalf("got %d videos, want 1", len(videos))
+	}
+	checkAttrs(t, "video", videos[0].Attrs, map[string]string{
+		"provider": "mpvideo",
+		"file":     "https://mpvideo.qpic.cn/hd.mp4",
+		"poster":   "https://mmbiz.qpic.cn/cover/0",
+		"src":      "https://mp.weixin.qq.com/s/video",
+		"title":    "演示视频",
+	})
+}
+
+func TestNormalArticleIsNotScriptPost(t *testing.T) {
+	// 普通文章的 script 里也可能有这些变量，正文不为空时按正文解析
+	html := `<html><body><div id="img-content"><h1 id="activity-name">标题</h1><div id="js_content"><p>正文</p></div></div>
+<script>var content_noencode = "摘要"; var __mpVideoTransInfo = [{ url: "https://mpvideo.qpic.cn/a.mp4" }];</script></body></html>`
+	article, err := ParseFromHTMLStringWithOptions(html, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := describe(article.Content); got != "text(正文)" {
+		t.Errorf("content = %s, want text(正文)", got)
+	}
+}
+
+func TestJsObjects(t *testing.T) {
+	script := `var list = [{ a: "x", nested: { b: "}" } }, { a: 'y]', arr: [1, 2] }]; var other = [{a: "z"}];`
+	objects := jsObjects(script, "list")
+	if len(objects) != 2 {
+		t.Fatalf("got %d objects: %q", len(objects), objects)
+	}
+	for i, want := range []string{"x", "y]"} {
+		if got := jsVar(objects[i], "a"); got != want {
+			t.Errorf("object %d: a = %q, want %q", i, got, want)
+		}
+	}
+	if objects := jsObjects(script, "missing"); objects != nil {
+		t.Errorf("missing array: got %q", objects)
+	}
+}
@@ -0,0 +1,85 @@
+package parse
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const (
+	reprintURL  = "https://mp.weixin.qq.com/s/reprint"
+	originalURL = "https://mp.weixin.qq.com/s/original"
+)
+
+// 转载的文章：正文前有“以下文章来源于原文号”的原文链接
+const reprintPage = `<html><head><script>var msg_link = "` + reprintURL + `";</script></head><body>
+<div id="img-content"><h1 id="activity-name">转载标题</h1>
+<a id="js_share_source" href="` + originalURL + `"><span id="js_share_author">原文号</span></a>
+<div id="js_content"><p>转载的正文</p></div></div></body></html>`
+
+const originalPage = `<html><head><script>var msg_link = "` + originalURL + `";</script></head><body>
+<div id="img-content"><h1 id="activity-name">原文标题</h1><div id="js_content"><p>原文的正文</p></div></div></body></html>`
+
+const deletedPage = `<html><body><div class="weui-msg"><h2 class="weui-msg__title">该内容已被发布者删除</h2></div></body></html>`
+
+// pageFetcher 按 url 返回页面，没有的页面返回 404；err 不为空时所有请求都失败
+type pageFetcher struct {
+	pages map[string]string
+	err   error
+}
+
+func (f pageFetcher) Do(req *http.Request) (*http.Response, error) {
+	if f.err != nil && req.URL.String() != reprintURL {
+		return nil, f.err
+	}
+	body, ok := f.pages[req.URL.String()]
+	status := 200
+	if !ok {
+		status = 404
+	}
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestFollowShare(t *testing.T) {
+	errNetwork := errors.New("connection reset")
+	tests := []struct {
+		name      string
+		fetcher   pageFetcher
+		wantTitle string
+		wantErr   error // FollowErr 应当包含的错误，为 nil 时应当没有 FollowErr
+	}{
+		{"original", pageFetcher{pages: map[string]string{originalURL: originalPage}}, "原文标题", nil},
+		{"deleted", pageFetcher{pages: map[string]string{originalURL: deletedPage}}, "转载标题", ErrDeleted},
+		{"not found", pageFetcher{pages: map[string]string{}}, "转载标题", ErrNotFound},
+		{"network error", pageFetcher{err: errNetwork}, "转载标题", errNetwork},
+	}
+	for _, tt := range tests {
+		opts := Options{ImagePolicy: IMAGE_POLICY_URL, FollowShare: true, Fetcher: tt.fetcher}
+		article, err := ParseFromReaderWithOptions(strings.NewReader(reprintPage), opts)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if title := article.Title.Val.(string); title != tt.wantTitle {
+			t.Errorf("%s: title = %q, want %q", tt.name, title, tt.wantTitle)
+		}
+		if tt.wantErr == nil && article.FollowErr != nil || tt.wantErr != nil && !errors.Is(article.FollowErr, tt.wantErr) {
+			t.Errorf("%s: FollowErr = %v, want %v", tt.name, article.FollowErr, tt.wantErr)
+		}
+		// 无论转换的是哪一篇，都保留转载的出处
+		md := article.Metadata
+		if md.URL != reprintURL || md.ShareURL != originalURL || md.ShareAccount != "原文号" {
+			t.Errorf("%s: URL = %q, ShareURL = %q, ShareAccount = %q", tt.name, md.URL, md.ShareURL, md.ShareAccount)
+		}
+	}
+}
+
+func TestWithoutFollowShare(t *testing.T) {
+	fetcher := pageFetcher{err: errors.New("should not fetch")}
+	article, err := ParseFromReaderWithOptions(strings.NewReader(reprintPage), Options{ImagePolicy: IMAGE_POLICY_URL, Fetcher: fetcher})
+	if err != nil || article.Title.Val.(string) != "转载标题" || article.FollowErr != nil {
+		t.Fatalf("title = %v, FollowErr = %v, err = %v", article.Title.Val, article.FollowErr, err)
+	}
+}
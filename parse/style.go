@@ -0,0 +1,313 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 公众号编辑器很少用 strong、h2 之类的标签，强调和小标题基本都是靠 span/section 上的内联样式实现的，
+// 这里根据 style 属性推断出粗体、斜体和标题
+
+const (
+	// DefaultHeaderThreshold 默认的标题推断阈值（字号比例）
+	DefaultHeaderThreshold = 1.15
+	// 微信正文默认字号
+	defaultBaseFontSize = 17
+	// 超过这个字数的段落不会被当作标题
+	maxHeaderRunes = 40
+)
+
+// parseStyle 把 style 属性解析成 属性名 => 值
+func parseStyle(s *goquery.Selection) map[string]string {
+	styles := make(map[string]string)
+	style, exists := s.Attr("style")
+	if !exists {
+		return styles
+	}
+	for _, decl := range strings.Split(style, ";") {
+		kv := strings.SplitN(decl, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		val := strings.TrimSpace(strings.Replace(kv[1], "!important", "", 1))
+		styles[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.ToLower(val)
+	}
+	return styles
+}
+
+// styleMarks 从内联样式中得到修饰标记
+func styleMarks(s *goquery.Selection) []string {
+	var marks []string
+	styles := parseStyle(s)
+	if isBoldWeight(styles["font-weight"]) {
+		marks = append(marks, MARK_BOLD)
+	}
+	if fs := styles["font-style"]; fs == "italic" || fs == "oblique" {
+		marks = append(marks, MARK_ITALIC)
+	}
+	decoration := styles["text-decoration"] + " " + styles["text-decoration-line"]
+	if strings.Contains(decoration, "line-through") {
+		marks = append(marks, MARK_STRIKE)
+	}
+	if strings.Contains(decoration, "underline") {
+		marks = append(marks, MARK_UNDERLINE)
+	}
+	// 只有 span 上的背景色算高亮，section、p 上的背景色多是排版用的底色
+	if s.Is("span") && isHighlightBackground(styles) {
+		marks = append(marks, MARK_HIGHLIGHT)
+	}
+	return marks
+}
+
+// isHighlightBackground 背景色不是透明或白色时视为高亮
+func isHighlightBackground(styles map[string]string) bool {
+	bg := styles["background-color"]
+	if bg == "" && !strings.Contains(styles["background"], "url(") {
+		bg = styles["background"]
+	}
+	switch strings.ReplaceAll(bg, " ", "") {
+	case "", "transparent", "none", "inherit", "initial", "unset", "white", "#fff", "#ffffff",
+		"rgb(255,255,255)", "rgba(255,255,255,1)", "rgba(0,0,0,0)":
+		return false
+	}
+	return true
+}
+
+func isBoldWeight(weight string) bool {
+	if weight == "bold" || weight == "bolder" {
+		return true
+	}
+	n, err := strconv.Atoi(weight)
+	return err == nil && n >= 600
+}
+
+// parseFontSize 把 font-size 的值换算成px，无法识别时返回0
+func parseFontSize(val string) float64 {
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"px", 1},
+		{"pt", 4.0 / 3},
+		{"rem", 16},
+		{"em", 16},
+		{"%", 16.0 / 100},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(val, u.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(val, u.suffix)), 64)
+			if err != nil {
+				return 0
+			}
+			return n * u.scale
+		}
+	}
+	return 0
+}
+
+// textNodes 返回 s 下所有非空白的文本节点
+func textNodes(s *goquery.Selection) []*goquery.Selection {
+	var nodes []*goquery.Selection
+	s.Contents().Each(func(i int, sc *goquery.Selection) {
+		if goquery.NodeName(sc) == "#text" {
+			if strings.TrimSpace(sc.Text()) != "" {
+				nodes = append(nodes, sc)
+			}
+		} else {
+			nodes = append(nodes, textNodes(sc)...)
+		}
+	})
+	return nodes
+}
+
+// inheritedStyle 沿着祖先节点向上找到最近声明的样式值，到 #js_content 为止
+func inheritedStyle(s *goquery.Selection, prop string) string {
+	for p := s.Parent(); p.Length() > 0; p = p.Parent() {
+		if val, ok := parseStyle(p)[prop]; ok && val != "inherit" {
+			return val
+		}
+		if p.Is("#js_content") {
+			break
+		}
+	}
+	return ""
+}
+
+// isBoldText 文本节点是否为粗体（祖先有 strong/b 标签或 font-weight 为粗体）
+func isBoldText(s *goquery.Selection) bool {
+	if s.ParentsFiltered("strong,b").Length() > 0 {
+		return true
+	}
+	return isBoldWeight(inheritedStyle(s, "font-weight"))
+}
+
+// baseFontSize 统计正文中字数最多的字号作为正文字号
+func baseFontSize(content *goquery.Selection) float64 {
+	runes := make(map[float64]int)
+	for _, tn := range textNodes(content) {
+		size := parseFontSize(inheritedStyle(tn, "font-size"))
+		if size == 0 {
+			size = defaultBaseFontSize
+		}
+		runes[size] += utf8.RuneCountInString(strings.TrimSpace(tn.Text()))
+	}
+	var base float64 = defaultBaseFontSize
+	most := 0
+	for size, n := range runes {
+		if n > most || (n == most && size < base) {
+			base, most = size, n
+		}
+	}
+	return base
+}
+
+// hasMediaOrMath s 中是否有视频、音频、卡片等媒体或公式，这类段落不会是标题
+func hasMediaOrMath(s *goquery.Selection) bool {
+	return s.Find("*").FilterFunction(func(i int, sc *goquery.Selection) bool {
+		return isMedia(sc) || isMath(sc)
+	}).Length() > 0
+}
+
+// inferHeader 根据 p/section 段落的内联样式推断它是不是小标题，是则返回标题级别，否则返回0
+//   - 字号与正文字号之比不小于阈值：比例越大级别越高（h2~h4）
+//   - 字号不大，但整段加粗，并且带背景色或居中且是彩色文字：h4
+func inferHeader(s *goquery.Selection, opts *Options) int {
+	if opts.HeaderThreshold < 0 || opts.baseFontSize == 0 || !s.Is("p,section") {
+		return 0
+	}
+	text := strings.TrimSpace(removeBrAndBlank(s.Text()))
+	if text == "" || utf8.RuneCountInString(text) > maxHeaderRunes {
+		return 0
+	}
+	if s.Find("img,table,pre,ol,ul,blockquote,a,svg,video,audio").Length() > 0 || hasMediaOrMath(s) || s.ParentsFiltered("li,blockquote,td,th").Length() > 0 {
+		return 0
+	}
+	// 包含多个有文字的段落，说明是容器而不是标题
+	if s.Find("p,section").FilterFunction(func(i int, sc *goquery.Selection) bool {
+		return sc.Find("p,section").Length() == 0 && strings.TrimSpace(sc.Text()) != ""
+	}).Length() > 1 {
+		return 0
+	}
+
+	nodes := textNodes(s)
+	if len(nodes) == 0 {
+		return 0
+	}
+	var minSize float64
+	allBold := true
+	for _, tn := range nodes {
+		size := parseFontSize(inheritedStyle(tn, "font-size"))
+		if size == 0 {
+			size = opts.baseFontSize
+		}
+		if minSize == 0 || size < minSize {
+			minSize = size
+		}
+		if !isBoldText(tn) {
+			allBold = false
+		}
+	}
+
+	threshold := opts.HeaderThreshold
+	ratio := minSize / opts.baseFontSize
+	switch {
+	case ratio >= threshold*1.3:
+		return 2
+	case ratio >= threshold*1.1:
+		return 3
+	case ratio >= threshold:
+		return 4
+	}
+	if allBold && (hasBackground(s, nodes) || (isCentered(s, nodes) && isColored(nodes))) {
+		return 4
+	}
+	return 0
+}
+
+// isCentered 段落本身或包住全部文字的节点是否居中
+func isCentered(s *goquery.Selection, nodes []*goquery.Selection) bool {
+	if parseStyle(s)["text-align"] == "center" {
+		return true
+	}
+	for _, tn := range nodes {
+		if inheritedStyle(tn, "text-align") != "center" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasBackground 全部文字是否都带有背景色
+func hasBackground(s *goquery.Selection, nodes []*goquery.Selection) bool {
+	if isVisibleBackground(parseStyle(s)["background-color"]) {
+		return true
+	}
+	for _, tn := range nodes {
+		if !isVisibleBackground(inheritedStyle(tn, "background-color")) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVisibleBackground(bg string) bool {
+	switch bg {
+	case "", "transparent", "rgb(255, 255, 255)", "rgba(0, 0, 0, 0)", "#fff", "#ffffff", "white":
+		return false
+	}
+	return true
+}
+
+var rgbReg = regexp.MustCompile(`rgba?\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)`)
+
+// isColored 全部文字的颜色是否都是彩色（非黑白灰）
+func isColored(nodes []*goquery.Selection) bool {
+	for _, tn := range nodes {
+		r, g, b, ok := parseColor(inheritedStyle(tn, "color"))
+		if !ok {
+			return false
+		}
+		hi, lo := r, r
+		for _, c := range []int{g, b} {
+			if c > hi {
+				hi = c
+			}
+			if c < lo {
+				lo = c
+			}
+		}
+		if hi-lo < 40 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseColor 解析 rgb()/rgba()/#rrggbb/#rgb 形式的颜色
+func parseColor(val string) (int, int, int, bool) {
+	if m := rgbReg.FindStringSubmatch(val); m != nil {
+		r, _ := strconv.Atoi(m[1])
+		g, _ := strconv.Atoi(m[2])
+		b, _ := strconv.Atoi(m[3])
+		return r, g, b, true
+	}
+	if strings.HasPrefix(val, "#") {
+		hex := val[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return 0, 0, 0, false
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		return int(n >> 16), int(n >> 8 & 0xff), int(n & 0xff), true
+	}
+	return 0, 0, 0, false
+}
@@ -0,0 +1,67 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStyleMarks(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{`<p><span style="font-weight: bold;">粗</span></p>`, "bold(粗)"},
+		{`<p><span style="font-weight: 700 !important;">粗</span></p>`, "bold(粗)"},
+		{`<p><span style="font-weight: 400;">常规</span></p>`, "text(常规)"},
+		{`<p><span style="font-style: italic;">斜</span></p>`, "italic(斜)"},
+		{`<p><span style="font-weight: bold; font-style: italic;">粗斜</span></p>`, "bolditalic{bold,italic}(粗斜)"},
+		{`<p><span style="text-decoration: line-through;">删</span></p>`, "strike(删)"},
+		{`<p><span style="text-decoration-line: underline;">下</span></p>`, "underline(下)"},
+		{`<p><span style="background-color: rgb(255, 255, 0);">高亮</span></p>`, "highlight(高亮)"},
+		{`<p><span style="background-color: rgb(255, 255, 255);">白底</span></p>`, "text(白底)"},
+		// 样式写在外层 span 上，里面的 strong 叠加
+		{`<p><span style="font-style: italic;">a<strong>b</strong></span></p>`, "italic(a) bolditalic{bold,italic}(b)"},
+	}
+	for _, tt := range tests {
+		if got := describe(parseContent(t, tt.html, Options{})); got != tt.want {
+			t.Errorf("%s\n got: %s\nwant: %s", tt.html, got, tt.want)
+		}
+	}
+}
+
+// 正文为 16px，用来确定正文字号
+const bodyParagraph = `<p style="font-size: 16px;">这是一段足够长的正文，用来让统计出来的正文字号是十六像素。</p>`
+
+func TestInferHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		html      string
+		threshold float64
+		want      string
+	}{
+		{"large", `<p style="font-size: 24px;">大标题</p>`, 0, "h2(大标题)"},
+		{"medium", `<p style="font-size: 21px;">中标题</p>`, 0, "h3(中标题)"},
+		{"small", `<section style="font-size: 19px;"><span>小标题</span></section>`, 0, "h4(小标题)"},
+		{"body size", `<p style="font-size: 16px;">普通段落</p>`, 0, "text(普通段落)"},
+		{"bold centered colored", `<p style="text-align: center;"><span style="font-weight: bold; color: rgb(0, 112, 192);">彩色标题</span></p>`, 0, "h4(彩色标题)"},
+		{"bold with background", `<p><span style="font-weight: bold; background-color: rgb(255, 200, 0);">底色标题</span></p>`, 0, "h4(底色标题)"},
+		{"bold black", `<p style="text-align: center;"><strong>只是加粗</strong></p>`, 0, "bold(只是加粗)"},
+		{"too long", `<p style="font-size: 24px;">这一段虽然字号很大但是字数超过了四十个字所以不会被当成标题而是当成普通的段落来处理的啊</p>`, 0, "text(这一段虽然字号很大但是字数超过了四十个字所以不会被当成标题而是当成普通的段落来处理的啊)"},
+		{"contains link", `<p style="font-size: 24px;"><a href="https://a.com/">链接</a></p>`, 0, "link(链接->https://a.com/)"},
+		{"contains svg", `<section style="font-size: 24px;"><svg viewBox="0 0 1 1"><image href="https://a.com/1.png"/></svg>图注</section>`, 0, "image(https://a.com/1.png) text(图注)"},
+		{"contains video", `<section style="font-size: 24px;"><iframe src="https://v.qq.com/x"></iframe>视频说明</section>`, 0, "video text(视频说明)"},
+		{"contains audio", `<section style="font-size: 24px;"><mpvoice name="录音" voice_encode_fileid="a"></mpvoice>音频说明</section>`, 0, "audio text(音频说明)"},
+		{"contains math", `<section style="font-size: 24px;"><span data-formula="E=mc^2"></span>公式说明</section>`, 0, "math(E=mc^2) text(公式说明)"},
+		{"higher threshold", `<p style="font-size: 21px;">中标题</p>`, 1.5, "text(中标题)"},
+		{"disabled", `<p style="font-size: 24px;">大标题</p>`, -1, "text(大标题)"},
+	}
+	for _, tt := range tests {
+		opts := Options{HeaderThreshold: tt.threshold, ImagePolicy: IMAGE_POLICY_URL}
+		got := describe(parseContent(t, bodyParagraph+tt.html, opts))
+		// 去掉正文段落
+		got = strings.TrimPrefix(got, describe(parseContent(t, bodyParagraph, opts))+" ")
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package parse
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseTable 把 table 解析成 Table 模型，单元格内容按普通段落解析
+func parseTable(s *goquery.Selection, opts *Options) ([]Piece, error) {
+	var table Table
+	var err error
+	var theadRows int
+	s.Children().EachWithBreak(func(i int, sc *goquery.Selection) bool {
+		var rows []*goquery.Selection
+		if sc.Is("tr") {
+			rows = append(rows, sc)
+		} else if sc.Is("thead,tbody,tfoot") {
+			sc.ChildrenFiltered("tr").Each(func(i int, tr *goquery.Selection) {
+				rows = append(rows, tr)
+			})
+		}
+		for _, tr := range rows {
+			var row TableRow
+			if row, err = parseTableRow(tr, opts); err != nil {
+				return false
+			}
+			if len(row) == 0 {
+				continue
+			}
+			if sc.Is("thead") {
+				theadRows++
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(table.Rows) == 0 {
+		return nil, nil
+	}
+	table.HasHeader = theadRows > 0 || isHeaderRow(table.Rows[0])
+	if table.HasHeader {
+		for i := range table.Rows[0] {
+			table.Rows[0][i].IsHeader = true
+		}
+	}
+	return []Piece{{TABLE, table, nil}}, nil
+}
+
+func parseTableRow(tr *goquery.Selection, opts *Options) (TableRow, error) {
+	var row TableRow
+	var err error
+	tr.ChildrenFiltered("th,td").EachWithBreak(func(i int, td *goquery.Selection) bool {
+		var content []Piece
+		if content, err = parseSection(td, opts, NULL); err != nil {
+			return false
+		}
+		row = append(row, TableCell{
+			Content:  trimBr(content),
+			IsHeader: td.Is("th"),
+			ColSpan:  spanAttr(td, "colspan"),
+			RowSpan:  spanAttr(td, "rowspan"),
+			Align:    cellAlign(td),
+		})
+		return true
+	})
+	return row, err
+}
+
+// isHeaderRow 整行都是 th，或者整行有字的单元格都是粗体，就当作表头
+func isHeaderRow(row TableRow) bool {
+	allTh, allBold, hasText := true, true, false
+	for _, cell := range row {
+		if !cell.IsHeader {
+			allTh = false
+		}
+		for _, p := range cell.Content {
+			switch p.Type {
+			case BR:
+			case NORMAL_TEXT:
+				if strings.TrimSpace(p.Val.(string)) != "" {
+					allBold, hasText = false, true
+				}
+			case BOLD_TEXT, BOLD_ITALIC_TEXT:
+				hasText = true
+			default:
+				allBold = false
+			}
+		}
+	}
+	return allTh || (allBold && hasText)
+}
+
+func spanAttr(s *goquery.Selection, name string) int {
+	val, _ := s.Attr(name)
+	n, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func cellAlign(s *goquery.Selection) string {
+	align, _ := s.Attr("align")
+	if textAlign := parseStyle(s)["text-align"]; textAlign != "" {
+		align = textAlign
+	}
+	switch align = strings.ToLower(align); align {
+	case "left", "center", "right":
+		return align
+	}
+	return ""
+}
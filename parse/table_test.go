@@ -0,0 +1,92 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describeTable 把表格写成一行：表头单元格前加 *，跨行跨列写成 @列x行，对齐写成 :center
+func describeTable(table Table) string {
+	var rows []string
+	for _, row := range table.Rows {
+		var cells []string
+		for _, cell := range row {
+			s := describe(cell.Content)
+			if cell.IsHeader {
+				s = "*" + s
+			}
+			if cell.ColSpan > 1 || cell.RowSpan > 1 {
+				s += fmt.Sprintf("@%dx%d", cell.ColSpan, cell.RowSpan)
+			}
+			if cell.Align != "" {
+				s += ":" + cell.Align
+			}
+			cells = append(cells, s)
+		}
+		rows = append(rows, "["+strings.Join(cells, " | ")+"]")
+	}
+	return fmt.Sprintf("header=%v %s", table.HasHeader, strings.Join(rows, " "))
+}
+
+func TestParseTable(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			"th header",
+			`<table><tbody><tr><th>名称</th><th>数量</th></tr><tr><td>苹果</td><td>3</td></tr></tbody></table>`,
+			"header=true [*text(名称) | *text(数量)] [text(苹果) | text(3)]",
+		},
+		{
+			"thead",
+			`<table><thead><tr><td>名称</td></tr></thead><tbody><tr><td>苹果</td></tr></tbody></table>`,
+			"header=true [*text(名称)] [text(苹果)]",
+		},
+		{
+			"bold first row",
+			`<table><tr><td><strong>名称</strong></td><td><b>数量</b></td></tr><tr><td>苹果</td><td>3</td></tr></table>`,
+			"header=true [*bold(名称) | *bold(数量)] [text(苹果) | text(3)]",
+		},
+		{
+			"no header",
+			`<table><tr><td>苹果</td><td><strong>3</strong></td></tr></table>`,
+			"header=false [text(苹果) | bold(3)]",
+		},
+		{
+			"spans",
+			`<table><tr><td colspan="2">合并</td></tr><tr><td rowspan="2">左</td><td>右</td></tr><tr><td>右2</td></tr></table>`,
+			"header=false [text(合并)@2x1] [text(左)@1x2 | text(右)] [text(右2)]",
+		},
+		{
+			"align",
+			`<table><tr><td align="right">右</td><td style="text-align: center;">中</td><td align="justify">两端</td></tr></table>`,
+			"header=false [text(右):right | text(中):center | text(两端)]",
+		},
+		{
+			"cell content",
+			`<table><tr><td><p>第一段</p><p>第二段</p></td><td><ul><li>项</li></ul></td></tr></table>`,
+			"header=false [text(第一段) text(第二段) | ul[text(项)]]",
+		},
+		{
+			"empty rows skipped",
+			`<table><tr></tr><tr><td>唯一</td></tr></table>`,
+			"header=false [text(唯一)]",
+		},
+	}
+	for _, tt := range tests {
+		tables := collect(parseContent(t, tt.html, Options{}), TABLE)
+		if len(tables) != 1 {
+			t.Errorf("%s: got %d tables, want 1", tt.name, len(tables))
+			continue
+		}
+		if got := describeTable(tables[0].Val.(Table)); got != tt.want {
+			t.Errorf("%s\n got: %s\nwant: %s", tt.name, got, tt.want)
+		}
+	}
+	if tables := collect(parseContent(t, `<table><tbody></tbody></table>`, Options{}), TABLE); len(tables) != 0 {
+		t.Errorf("empty table: got %d tables, want 0", len(tables))
+	}
+}
@@ -0,0 +1,56 @@
+package parse
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// 提示页面上放原因的元素：新版为 weui-msg，旧版为 global_error_msg
+const unavailableReasonSelector = ".weui-msg__title,.weui-msg__desc,.global_error_msg,.page_msg .title,.page_msg .desc"
+
+// 提示文字中的关键词 => 错误类型，按顺序匹配
+var unavailableKinds = []struct {
+	keywords []string
+	kind     error
+}{
+	{[]string{"环境异常", "完成验证", "去验证", "访问过于频繁"}, ErrVerify},
+	{[]string{"违规", "违反", "投诉", "停止访问", "屏蔽", "封禁"}, ErrBanned},
+	{[]string{"删除", "注销"}, ErrDeleted},
+	{[]string{"过期", "已失效"}, ErrExpired},
+}
+
+// unavailablePage 没有正文的页面是否是微信的提示页（删除、违规、过期、验证），是时返回 *UnavailableError
+func unavailablePage(doc *goquery.Document) error {
+	var reasons []string
+	doc.Find(unavailableReasonSelector).Each(func(i int, s *goquery.Selection) {
+		if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+			reasons = append(reasons, text)
+		}
+	})
+	reason := strings.Join(reasons, " ")
+	// 找不到提示元素时按页面上看得到的文字判断，文字不长时作为原因
+	text := reason
+	if text == "" {
+		body := doc.Find("body").Clone()
+		body.Find("script,style,noscript,template").Remove()
+		text = strings.Join(strings.Fields(doc.Find("title").Text()+" "+body.Text()), " ")
+	}
+	for _, k := range unavailableKinds {
+		for _, keyword := range k.keywords {
+			if strings.Contains(text, keyword) {
+				if reason == "" {
+					reason = keyword
+					if len([]rune(text)) <= 100 {
+						reason = text
+					}
+				}
+				return &UnavailableError{k.kind, reason}
+			}
+		}
+	}
+	if reason != "" {
+		return &UnavailableError{ErrUnavailable, reason}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// 提示页面，%s 处放提示内容
+const noticePage = `<html><head><title>%s</title></head><body>%s<script>var msg_title = "";</script></body></html>`
+
+func weuiMsg(title, desc string) string {
+	return `<div class="weui-msg"><div class="weui-msg__text-area"><h2 class="weui-msg__title">` + title +
+		`</h2><p class="weui-msg__desc">` + desc + `</p></div></div>`
+}
+
+func TestUnavailablePage(t *testing.T) {
+	tests := []struct {
+		name   string
+		title  string
+		body   string
+		kind   error
+		reason string
+	}{
+		{"verify", "", weuiMsg("环境异常", "当前环境异常，完成验证后即可继续访问。") + `<a id="js_verify">去验证</a>`,
+			ErrVerify, "环境异常 当前环境异常，完成验证后即可继续访问。"},
+		{"too frequent", "", weuiMsg("访问过于频繁，请稍后再试", ""), ErrVerify, "访问过于频繁，请稍后再试"},
+		{"banned", "", weuiMsg("此内容因违规无法查看", "该内容已被停止访问"), ErrBanned, "此内容因违规无法查看 该内容已被停止访问"},
+		{"complaint", "", `<div class="global_error_msg">此内容被投诉且经审核涉嫌侵权，无法查看。</div>`,
+			ErrBanned, "此内容被投诉且经审核涉嫌侵权，无法查看。"},
+		{"deleted", "", weuiMsg("该内容已被发布者删除", ""), ErrDeleted, "该内容已被发布者删除"},
+		{"account deleted", "", `<div class="page_msg"><div class="inner"><p class="title">此帐号已自主注销，内容无法查看</p></div></div>`,
+			ErrDeleted, "此帐号已自主注销，内容无法查看"},
+		{"expired", "", weuiMsg("链接已过期", ""), ErrExpired, "链接已过期"},
+		{"other notice", "", weuiMsg("参数错误", "请检查链接是否完整"), ErrUnavailable, "参数错误 请检查链接是否完整"},
+		{"bare text", "微信公众平台", `<p>该内容已被发布者删除</p>`, ErrDeleted, "微信公众平台 该内容已被发布者删除"},
+		{"long bare text", "", `<p>` + strings.Repeat("页面加载中", 30) + `该内容已被删除</p>`, ErrDeleted, "删除"},
+	}
+	for _, tt := range tests {
+		_, err := ParseFromHTMLStringWithOptions(fmt.Sprintf(noticePage, tt.title, tt.body), Options{})
+		var unavailable *UnavailableError
+		if !errors.As(err, &unavailable) {
+			t.Errorf("%s: err = %v, want *UnavailableError", tt.name, err)
+			continue
+		}
+		if unavailable.Kind != tt.kind || unavailable.Reason != tt.reason {
+			t.Errorf("%s: got %v / %q, want %v / %q", tt.name, unavailable.Kind, unavailable.Reason, tt.kind, tt.reason)
+		}
+		if !errors.Is(err, tt.kind) || !errors.Is(err, ErrUnavailable) || errors.Is(err, ErrNoContent) {
+			t.Errorf("%s: errors.Is does not match %v and ErrUnavailable only", tt.name, tt.kind)
+		}
+		if want := tt.kind.Error() + ": " + tt.reason; err.Error() != want {
+			t.Errorf("%s: Error() = %q, want %q", tt.name, err.Error(), want)
+		}
+	}
+}
+
+func TestNoContent(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+	}{
+		{"empty page", fmt.Sprintf(noticePage, "", "")},
+		{"unrelated page", fmt.Sprintf(noticePage, "首页", "<p>欢迎</p>")},
+		{"no js_content", `<html><body><div id="img-content"><h1 id="activity-name">标题</h1></div></body></html>`},
+	}
+	for _, tt := range tests {
+		_, err := ParseFromHTMLStringWithOptions(tt.html, Options{})
+		if !errors.Is(err, ErrNoContent) || errors.Is(err, ErrUnavailable) {
+			t.Errorf("%s: err = %v, want ErrNoContent", tt.name, err)
+		}
+	}
+
+	// 正文里提到“删除”的文章不是提示页
+	article, err := ParseFromHTMLStringWithOptions(fmt.Sprintf(articlePage, "<p>如何删除聊天记录</p>"), Options{})
+	if err != nil || describe(article.Content) != "text(如何删除聊天记录)" {
+		t.Errorf("article mentioning 删除: %s, %v", describe(article.Content), err)
+	}
+}
@@ -1,8 +1,8 @@
 package server
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"regexp"
 	"strings"
@@ -12,8 +12,17 @@ import (
 	"github.com/fengxxc/wechatmp2markdown/util"
 )
 
-func Start(addr string) {
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+// Start 启动 web server，opts 中除 ImagePolicy 外的解析选项（如图片下载的并发数、重试次数）对所有请求生效。
+// 监听失败时返回错误
+func Start(addr string, opts parse.Options) error {
+	fmt.Printf("wechatmp2markdown server listening on %s\n", addr)
+	return http.ListenAndServe(addr, Handler(opts))
+}
+
+// Handler 处理转换请求的 http.Handler
+func Handler(opts parse.Options) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		rawQuery := r.URL.RawQuery
 		paramsMap := parseParams(rawQuery)
 
@@ -23,29 +32,74 @@ func Start(addr string) {
 		imageArgValue := paramsMap["image"]
 		fmt.Printf("     image: %s\n", imageArgValue)
 		imagePolicy := parse.ImageArgValue2ImagePolicy(imageArgValue)
+		formatArgValue := paramsMap["format"]
+		fmt.Printf("    format: %s\n", formatArgValue)
+		flavor := format.FlavorArgValue2Flavor(paramsMap["flavor"])
+		fmt.Printf("    flavor: %s\n", paramsMap["flavor"])
 
 		if wechatmpURL == "" {
 			w.WriteHeader(http.StatusBadRequest)
 			w.Write([]byte(defHTML))
 			return
 		}
+		renderer, err := format.GetRenderer(formatArgValue)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		parseOpts := opts
+		parseOpts.ImagePolicy = imagePolicy
+		if follow := paramsMap["follow"]; follow == "true" || follow == "1" {
+			parseOpts.FollowShare = true
+		}
+		articleStruct, err := parse.ParseFromURLWithOptions(wechatmpURL, parseOpts)
+		if err != nil {
+			fmt.Printf("parse url %s error: %v\n", wechatmpURL, err)
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		if articleStruct.FollowErr != nil {
+			fmt.Printf("parse url %s: %v, using the reprint\n", wechatmpURL, articleStruct.FollowErr)
+		}
+		result, saveFiles, err := renderer.Render(articleStruct, format.Options{Flavor: flavor, FrontMatter: flavor.DefaultFrontMatter()})
+		if err != nil {
+			fmt.Printf("render url %s error: %v\n", wechatmpURL, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		w.Header().Set("Content-Type", "application/octet-stream")
-		var articleStruct parse.Article = parse.ParseFromURL(wechatmpURL, imagePolicy)
-		title := articleStruct.Title.Val.(string)
-		mdString, saveImageBytes := format.Format(articleStruct)
-		if len(saveImageBytes) > 0 {
+		title := format.ArticleFileName(articleStruct)
+		fileName := title + "." + renderer.Ext()
+		if len(saveFiles) > 0 {
 			w.Header().Set("Content-Disposition", "attachment; filename="+title+".zip")
-			saveImageBytes[title] = []byte(mdString)
-			util.HttpDownloadZip(w, saveImageBytes)
+			saveFiles[fileName] = result
+			if err := util.HttpDownloadZip(w, saveFiles); err != nil {
+				fmt.Printf("write zip of %s error: %v\n", wechatmpURL, err)
+			}
 		} else {
-			w.Header().Set("Content-Disposition", "attachment; filename="+title+".md")
-			w.Write([]byte(mdString))
+			w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+			w.Write(result)
 		}
 	})
+	return mux
+}
 
-	fmt.Printf("wechatmp2markdown server listening on %s\n", addr)
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		log.Fatal(err)
+// errorStatus 根据解析错误的类型返回对应的http状态码
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, parse.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, parse.ErrVerify):
+		// 微信要求验证，稍后再试
+		return http.StatusServiceUnavailable
+	case errors.Is(err, parse.ErrUnavailable):
+		return http.StatusGone
+	case errors.Is(err, parse.ErrNoContent):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, parse.ErrImageFetch):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
@@ -66,6 +120,15 @@ var defHTML string = `
 		<li>
 			<strong>param 'image' is optional</strong>, value include: 'url' / 'save' / 'base64'(default)
 		</li>
+		<li>
+			<strong>param 'format' is optional</strong>, value include: 'markdown'(default) / 'text' / 'html' / 'epub' / 'docx' / 'org' / 'asciidoc' / 'rst'
+		</li>
+		<li>
+			<strong>param 'flavor' is optional</strong>, markdown flavor, value include: 'commonmark' / 'gfm' / 'obsidian' / 'typora' / 'hexo' / 'logseq'
+		</li>
+		<li>
+			<strong>param 'follow' is optional</strong>, 'true': convert the original article of a reprinted / shared article
+		</li>
 		<li>
 			<strong>example:</strong> http://localhost:8964/?url=https://mp.weixin.qq.com/s?__biz=aaaa==&mid=1111&idx=2&sn=bbbb&chksm=cccc&scene=123&image=save
 		</li>
@@ -76,17 +139,14 @@ var defHTML string = `
 
 func parseParams(rawQuery string) map[string]string {
 	result := make(map[string]string)
-	reg := regexp.MustCompile(`(&?image=)([a-z]+)`)
-	matcheImage := reg.FindStringSubmatch(rawQuery)
 	var urlParamFull string = rawQuery
-	if len(matcheImage) > 1 {
-		// 有image参数
-		imageParamFull := matcheImage[0]
-		urlParamFull = strings.Replace(rawQuery, imageParamFull, "", 1)
-
-		if len(matcheImage) > 2 {
-			imageParamVal := matcheImage[2]
-			result["image"] = imageParamVal
+	// url 参数的值里可能带有 &，先把其他参数摘出来，剩下的都算 url
+	for _, name := range []string{"image", "format", "flavor", "follow"} {
+		reg := regexp.MustCompile(`(&?` + name + `=)([a-z0-9]+)`)
+		matche := reg.FindStringSubmatch(urlParamFull)
+		if len(matche) > 2 {
+			urlParamFull = strings.Replace(urlParamFull, matche[0], "", 1)
+			result[name] = matche[2]
 		}
 	}
 	regUrl := regexp.MustCompile(`(&?url=)(.+)`)
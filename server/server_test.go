@@ -0,0 +1,47 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+func TestStartReturnsListenError(t *testing.T) {
+	if err := Start("127.0.0.1:-1", parse.Options{}); err == nil {
+		t.Fatal("Start on an invalid address: want error, got nil")
+	}
+}
+
+func TestHandlerWithoutURL(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(parse.Options{}).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: x", parse.ErrNotFound), http.StatusNotFound},
+		{&parse.UnavailableError{Kind: parse.ErrVerify}, http.StatusServiceUnavailable},
+		{&parse.UnavailableError{Kind: parse.ErrDeleted}, http.StatusGone},
+		{&parse.UnavailableError{Kind: parse.ErrBanned, Reason: "此内容因违规无法查看"}, http.StatusGone},
+		{&parse.UnavailableError{Kind: parse.ErrExpired}, http.StatusGone},
+		{&parse.UnavailableError{Kind: parse.ErrUnavailable, Reason: "参数错误"}, http.StatusGone},
+		{parse.ErrNoContent, http.StatusUnprocessableEntity},
+		{fmt.Errorf("%w: x", parse.ErrImageFetch), http.StatusBadGateway},
+		{errors.New("other"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -9,7 +9,11 @@ import (
 )
 
 func Test1() {
-	var articleStruct parse.Article = parse.ParseFromHTMLFile("./test/test1.html", parse.IMAGE_POLICY_BASE64)
+	articleStruct, err := parse.ParseFromHTMLFile("./test/test1.html", parse.IMAGE_POLICY_BASE64)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Println("-------------------test1.html parse-------------------")
 	fmt.Printf("%+v\n", articleStruct)
 
@@ -6,49 +6,64 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/fengxxc/wechatmp2markdown/format"
 	"github.com/fengxxc/wechatmp2markdown/parse"
 )
 
-// ConvertHTMLFileToTxt 将单个HTML文件转换为TXT
+// ConvertHTMLFile 将单个HTML文件转换为 renderer 对应的格式
 // htmlFilePath: HTML文件路径
 // outputPath: 输出路径，可以是目录或文件路径
-// 返回生成的TXT文件路径
-func ConvertHTMLFileToTxt(htmlFilePath string, outputPath string) (string, error) {
+// 返回生成的文件路径
+func ConvertHTMLFile(htmlFilePath string, outputPath string, parseOpts parse.Options, renderer format.Renderer, formatOpts format.Options) (string, error) {
 	// 检查HTML文件是否存在
 	if _, err := os.Stat(htmlFilePath); err != nil {
 		return "", fmt.Errorf("HTML文件不存在或无法访问: %v", err)
 	}
 
 	// 解析HTML文件
-	articleStruct := parse.ParseFromHTMLFile(htmlFilePath, parse.IMAGE_POLICY_URL)
+	articleStruct, err := parse.ParseFromHTMLFileWithOptions(htmlFilePath, parseOpts)
+	if err != nil {
+		return "", fmt.Errorf("解析HTML文件失败: %w", err)
+	}
+	printFollowError(htmlFilePath, articleStruct)
 
 	// 获取标题作为文件名
-	title := strings.TrimSpace(articleStruct.Title.Val.(string))
+	title := format.ArticleFileName(articleStruct)
+	ext := "." + renderer.Ext()
 
 	// 确定输出文件路径
-	var txtFilePath string
+	var outFilePath string
 
 	// 检查输出路径是目录还是文件
 	fileInfo, err := os.Stat(outputPath)
 	if err == nil && fileInfo.IsDir() {
-		// 如果是目录，则在该目录下创建以标题命名的TXT文件
-		txtFilePath = filepath.Join(outputPath, title+".txt")
-	} else if strings.HasSuffix(strings.ToLower(outputPath), ".txt") {
-		// 如果指定了TXT文件名，则直接使用
-		txtFilePath = outputPath
+		// 如果是目录，则在该目录下创建以标题命名的文件
+		outFilePath = filepath.Join(outputPath, title+ext)
+	} else if strings.HasSuffix(strings.ToLower(outputPath), ext) {
+		// 如果指定了文件名，则直接使用
+		outFilePath = outputPath
 	} else {
-		// 否则，在指定路径下创建以标题命名的TXT文件
-		txtFilePath = outputPath + "/" + title + ".txt"
+		// 否则，在指定路径下创建以标题命名的文件
+		outFilePath = filepath.Join(outputPath, title+ext)
 	}
 
-	// 提取文本内容
-	result := extractTextContent(articleStruct)
-
-	// 保存TXT文件
-	err = saveTxtFile(txtFilePath, result)
+	// 保存文件
+	err = format.RenderAndSave(articleStruct, outFilePath, renderer, formatOpts)
 	if err != nil {
-		return "", fmt.Errorf("保存TXT文件失败: %v", err)
+		return "", fmt.Errorf("保存文件失败: %v", err)
 	}
 
-	return txtFilePath, nil
+	return outFilePath, nil
+}
+
+// ConvertHTMLFileToTxt 将单个HTML文件转换为TXT
+// htmlFilePath: HTML文件路径
+// outputPath: 输出路径，可以是目录或文件路径
+// 返回生成的TXT文件路径
+func ConvertHTMLFileToTxt(htmlFilePath string, outputPath string) (string, error) {
+	renderer, err := format.GetRenderer("text")
+	if err != nil {
+		return "", err
+	}
+	return ConvertHTMLFile(htmlFilePath, outputPath, parse.Options{ImagePolicy: parse.IMAGE_POLICY_URL}, renderer, format.Options{})
 }
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fengxxc/wechatmp2markdown/format"
+	"github.com/fengxxc/wechatmp2markdown/parse"
+)
+
+// accountArticle 公众号目录下的一篇文章
+type accountArticle struct {
+	article parse.Article
+	dirName string
+	time    time.Time
+}
+
+// BatchConvertHTMLFilesToEpub 把公众号目录下所有子目录中的文章合成电子书，每个公众号一本，章节按发布时间排序
+// basePath: 公众号目录的路径，例如 D:\WechatDownload\浙江宣传\
+// outputPath: 电子书的保存目录，为空时保存在 basePath 下
+// 返回生成的电子书路径
+func BatchConvertHTMLFilesToEpub(basePath string, outputPath string, parseOpts parse.Options, formatOpts format.Options) ([]string, error) {
+	// 确保基础路径存在
+	if _, err := os.Stat(basePath); err != nil {
+		return nil, fmt.Errorf("基础路径不存在或无法访问: %v", err)
+	}
+	if outputPath == "" {
+		outputPath = basePath
+	}
+	if err := os.MkdirAll(outputPath, 0o755); err != nil {
+		return nil, err
+	}
+
+	htmlFiles, err := findArticleHTMLFiles(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按公众号分组，取不到公众号名称时以目录名作为书名
+	defaultAccount := filepath.Base(filepath.Clean(basePath))
+	var accounts []string
+	books := make(map[string][]accountArticle)
+	for _, htmlFile := range htmlFiles {
+		fmt.Printf("开始处理: %s\n", htmlFile)
+		articleStruct, err := parse.ParseFromHTMLFileWithOptions(htmlFile, parseOpts)
+		if err != nil {
+			printParseError(htmlFile, err)
+			continue
+		}
+		printFollowError(htmlFile, articleStruct)
+		account := articleStruct.Metadata.AccountName
+		if account == "" {
+			account = defaultAccount
+		}
+		if _, ok := books[account]; !ok {
+			accounts = append(accounts, account)
+		}
+		dirName := filepath.Base(filepath.Dir(htmlFile))
+		books[account] = append(books[account], accountArticle{articleStruct, dirName, articlePublishTime(articleStruct, dirName)})
+	}
+
+	var epubFiles []string
+	for _, account := range accounts {
+		articles := books[account]
+		sort.SliceStable(articles, func(i, j int) bool {
+			if !articles[i].time.Equal(articles[j].time) {
+				return articles[i].time.Before(articles[j].time)
+			}
+			return articles[i].dirName < articles[j].dirName
+		})
+		var chapters []parse.Article
+		for _, a := range articles {
+			chapters = append(chapters, a.article)
+		}
+
+		book, err := format.RenderEpub(account, chapters, formatOpts)
+		if err != nil {
+			fmt.Printf("生成电子书失败 '%s': %v\n", account, err)
+			continue
+		}
+		epubFilePath := filepath.Join(outputPath, format.LegalizationFilename(account)+".epub")
+		if err := os.WriteFile(epubFilePath, book, 0o644); err != nil {
+			fmt.Printf("保存电子书失败 '%s': %v\n", epubFilePath, err)
+			continue
+		}
+		fmt.Printf("已生成: '%s'（%d 篇文章）\n", epubFilePath, len(chapters))
+		epubFiles = append(epubFiles, epubFilePath)
+	}
+	return epubFiles, nil
+}
+
+// articlePublishTime 文章的发布时间，页面里没有时取目录名开头的日期（见 BatchRenameDirectories）
+func articlePublishTime(article parse.Article, dirName string) time.Time {
+	if !article.Metadata.PublishTime.IsZero() {
+		return article.Metadata.PublishTime
+	}
+	if len(dirName) >= len("2006-01-02") {
+		if t, err := time.ParseInLocation("2006-01-02", dirName[:len("2006-01-02")], time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}